@@ -0,0 +1,310 @@
+package teq
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Nil reports error if x is not nil.
+// Typed nils such as (*T)(nil) in an interface are considered nil.
+func (teq Teq) Nil(t TestingT, x any) bool {
+	t.Helper()
+	defer reportInternalPanic(t)
+	if isNil(x) {
+		return true
+	}
+	t.Error("expected nil, got " + teq.describe(x))
+	return false
+}
+
+// NotNil reports error if x is nil.
+// Typed nils such as (*T)(nil) in an interface are considered nil.
+func (teq Teq) NotNil(t TestingT, x any) bool {
+	t.Helper()
+	defer reportInternalPanic(t)
+	if !isNil(x) {
+		return true
+	}
+	t.Error("expected not nil, got " + teq.describe(x))
+	return false
+}
+
+// Zero reports error if x is not equal to the zero value of its type.
+// The equality follows registered functions as Equal does.
+func (teq Teq) Zero(t TestingT, x any) bool {
+	t.Helper()
+	defer reportInternalPanic(t)
+	if x == nil {
+		return true
+	}
+	zero := reflect.Zero(reflect.TypeOf(x)).Interface()
+	eq, ok := teq.equal(t, zero, x)
+	if !ok {
+		return false
+	}
+	if !eq {
+		t.Error(teq.report(zero, x, teq.colorEnabled()))
+	}
+	return eq
+}
+
+// Len reports error if the length of x is not n.
+// x must be an array, a channel, a map, a slice or a string.
+func (teq Teq) Len(t TestingT, x any, n int) bool {
+	t.Helper()
+	defer reportInternalPanic(t)
+	l, ok := length(x)
+	if !ok {
+		t.Error(teq.describe(x) + " doesn't have length")
+		return false
+	}
+	if l != n {
+		t.Errorf("expected length %d, got %d: %s", n, l, teq.describe(x))
+		return false
+	}
+	return true
+}
+
+// Empty reports error if x is not empty.
+// x is empty if it is nil, has zero length, or is the zero value of its type.
+func (teq Teq) Empty(t TestingT, x any) bool {
+	t.Helper()
+	defer reportInternalPanic(t)
+	if isEmpty(x) {
+		return true
+	}
+	t.Error("expected empty, got " + teq.describe(x))
+	return false
+}
+
+// Contains reports error if container doesn't contain element.
+// container is a string, an array, a slice or a map.
+// A string must contain element as a substring, and a map must have element as a key.
+// Elements and keys are compared with the same rules as Equal, including registered functions.
+func (teq Teq) Contains(t TestingT, container, element any) bool {
+	t.Helper()
+	defer reportInternalPanic(t)
+	if s, ok := container.(string); ok {
+		sub, ok := element.(string)
+		if !ok {
+			t.Errorf("can't look for %s in a string", teq.describe(element))
+			return false
+		}
+		if strings.Contains(s, sub) {
+			return true
+		}
+		t.Errorf("%q doesn't contain %q", s, sub)
+		return false
+	}
+
+	var candidates []reflect.Value
+	v := reflect.ValueOf(container)
+	switch v.Kind() {
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			candidates = append(candidates, v.Index(i))
+		}
+	case reflect.Map:
+		candidates = sortedKeys(v)
+	default:
+		t.Error(teq.describe(container) + " is not a container")
+		return false
+	}
+	for _, c := range candidates {
+		eq, ok := teq.equal(t, c.Interface(), element)
+		if !ok {
+			return false
+		}
+		if eq {
+			return true
+		}
+	}
+	t.Errorf("%s doesn't contain %s", teq.describe(container), teq.describe(element))
+	return false
+}
+
+// ElementsMatch reports error if expected and actual don't have the same elements, ignoring the order.
+// Both must be arrays or slices. Elements are compared with the same rules as Equal, including registered functions.
+func (teq Teq) ElementsMatch(t TestingT, expected, actual any) bool {
+	t.Helper()
+	defer reportInternalPanic(t)
+	ve, va := reflect.ValueOf(expected), reflect.ValueOf(actual)
+	for _, v := range []reflect.Value{ve, va} {
+		if k := v.Kind(); k != reflect.Array && k != reflect.Slice {
+			t.Error(teq.describe(expected) + " and " + teq.describe(actual) + " must be arrays or slices")
+			return false
+		}
+	}
+
+	matched := make([]bool, va.Len())
+	var missing []reflect.Value
+	for i := 0; i < ve.Len(); i++ {
+		found := false
+		for j := 0; j < va.Len() && !found; j++ {
+			if matched[j] {
+				continue
+			}
+			eq, ok := teq.equal(t, ve.Index(i).Interface(), va.Index(j).Interface())
+			if !ok {
+				return false
+			}
+			if eq {
+				matched[j], found = true, true
+			}
+		}
+		if !found {
+			missing = append(missing, ve.Index(i))
+		}
+	}
+	var extra []reflect.Value
+	for j, m := range matched {
+		if !m {
+			extra = append(extra, va.Index(j))
+		}
+	}
+	if len(missing) == 0 && len(extra) == 0 {
+		return true
+	}
+	t.Error(teq.elementsReport(missing, extra))
+	return false
+}
+
+func (teq Teq) elementsReport(missing, extra []reflect.Value) (msg string) {
+	defer recoverCallbackPanic(&msg)
+	lines := []string{"elements don't match"}
+	if len(missing) > 0 {
+		lines = append(lines, "missing in actual:")
+		for _, v := range missing {
+			lines = append(lines, "- "+teq.formatValue(v))
+		}
+	}
+	if len(extra) > 0 {
+		lines = append(lines, "unexpected in actual:")
+		for _, v := range extra {
+			lines = append(lines, "+ "+teq.formatValue(v))
+		}
+	}
+	return teq.limitSize(strings.Join(lines, "\n"))
+}
+
+// ErrorIs reports error if no error in the chain of err matches target with errors.Is.
+func (teq Teq) ErrorIs(t TestingT, err, target error) bool {
+	t.Helper()
+	defer reportInternalPanic(t)
+	if errors.Is(err, target) {
+		return true
+	}
+	t.Errorf("expected %s in the error chain of %s", describeError(target), describeError(err))
+	return false
+}
+
+// ErrorAs reports error if no error in the chain of err can be assigned to target with errors.As.
+// target must be a non-nil pointer to a type implementing error or to an interface type, as errors.As requires.
+func (teq Teq) ErrorAs(t TestingT, err error, target any) bool {
+	t.Helper()
+	defer reportInternalPanic(t)
+	tv := reflect.ValueOf(target)
+	if tv.Kind() != reflect.Pointer || tv.IsNil() {
+		t.Error("target must be a non-nil pointer, got " + teq.describe(target))
+		return false
+	}
+	if et := tv.Type().Elem(); et.Kind() != reflect.Interface && !et.Implements(errorType) {
+		t.Errorf("target must be a pointer to an interface or a type implementing error, got %s", tv.Type())
+		return false
+	}
+	if errors.As(err, target) {
+		return true
+	}
+	t.Errorf("expected %s in the error chain of %s", tv.Type().Elem(), describeError(err))
+	return false
+}
+
+// Panics reports error if f doesn't panic.
+func (teq Teq) Panics(t TestingT, f func()) bool {
+	t.Helper()
+	if panicked(f) {
+		return true
+	}
+	t.Error("expected panic, but the function returned normally")
+	return false
+}
+
+func panicked(f func()) (p bool) {
+	defer func() {
+		if recover() != nil {
+			p = true
+		}
+	}()
+	f()
+	return false
+}
+
+// equal compares x and y for assertions other than Equal and NotEqual.
+// Values beyond MaxDepth are considered not equal. ok is false if a registered function panicked, which is reported.
+func (teq Teq) equal(t TestingT, x, y any) (eq bool, ok bool) {
+	t.Helper()
+	res := teq.compare(x, y)
+	if res.panicked != nil {
+		t.Error(res.panicked.String())
+		return false, false
+	}
+	if res.refused != nil {
+		t.Error(res.refused.String())
+		return false, false
+	}
+	return res.equal && res.exceeded == nil, true
+}
+
+// describe renders x in one line for failure messages.
+func (teq Teq) describe(x any) (s string) {
+	defer recoverCallbackPanic(&s)
+	return teq.formatAny(x)
+}
+
+func describeError(err error) string {
+	if err == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("%T(%q)", err, err.Error())
+}
+
+// reportInternalPanic reports a panic in teq itself as an error instead of crashing the test.
+func reportInternalPanic(t TestingT) {
+	if r := recover(); r != nil {
+		t.Helper()
+		t.Errorf("panic in github.com/seiyab/teq. please report issue. message: %v", r)
+	}
+}
+
+func isNil(x any) bool {
+	if x == nil {
+		return true
+	}
+	v := reflect.ValueOf(x)
+	switch v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Pointer, reflect.Slice, reflect.UnsafePointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+func length(x any) (int, bool) {
+	v := reflect.ValueOf(x)
+	switch v.Kind() {
+	case reflect.Array, reflect.Chan, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len(), true
+	}
+	return 0, false
+}
+
+func isEmpty(x any) bool {
+	if x == nil {
+		return true
+	}
+	if l, ok := length(x); ok {
+		return l == 0
+	}
+	return reflect.ValueOf(x).IsZero()
+}
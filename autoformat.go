@@ -0,0 +1,121 @@
+package teq
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+)
+
+var (
+	errorType         = reflect.TypeOf((*error)(nil)).Elem()
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// formatOf returns the format function for the type.
+// It is the registered one, or with AutoFormat, a function calling Error, String or MarshalText of the type.
+func (teq Teq) formatOf(ty reflect.Type) (reflect.Value, bool) {
+	if f, ok := teq.formats[ty]; ok {
+		return f, true
+	}
+	if !teq.AutoFormat || ty.Kind() == reflect.Interface || teq.noAutoFormat[ty] {
+		return reflect.Value{}, false
+	}
+	var method func(v reflect.Value) string
+	switch {
+	case ty.Implements(errorType):
+		method = func(v reflect.Value) string { return v.Interface().(error).Error() }
+	case ty.Implements(stringerType):
+		method = func(v reflect.Value) string { return v.Interface().(fmt.Stringer).String() }
+	case ty.Implements(textMarshalerType):
+		method = func(v reflect.Value) string {
+			b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+			if err != nil {
+				return fmt.Sprintf("<MarshalText failed: %v>", err)
+			}
+			return string(b)
+		}
+	default:
+		return reflect.Value{}, false
+	}
+	fty := reflect.FuncOf([]reflect.Type{ty}, []reflect.Type{reflect.TypeOf("")}, false)
+	return reflect.MakeFunc(fty, func(args []reflect.Value) []reflect.Value {
+		v := args[0]
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			// Only akashi passes nil pointers here, since formatFor skips them.
+			return []reflect.Value{reflect.ValueOf("<nil>")}
+		}
+		return []reflect.Value{reflect.ValueOf(method(v))}
+	}), true
+}
+
+// formatFor returns the format function for v.
+// It is formatOf for the type of v, except that AutoFormat doesn't apply to nil pointers
+// so that they are rendered as nil rather than by calling their methods.
+func (teq Teq) formatFor(v reflect.Value) (reflect.Value, bool) {
+	if _, ok := teq.formats[v.Type()]; !ok && v.Kind() == reflect.Pointer && v.IsNil() {
+		return reflect.Value{}, false
+	}
+	return teq.formatOf(v.Type())
+}
+
+// autoFormatTypes returns the types formatted by AutoFormat in the values, which must be told to akashi.
+// It doesn't look into formatted values, since their internals are not shown.
+func (teq Teq) autoFormatTypes(vs ...reflect.Value) []reflect.Type {
+	if !teq.AutoFormat {
+		return nil
+	}
+	var types []reflect.Type
+	seen := make(map[reflect.Type]bool)
+	type ref struct {
+		ptr uintptr
+		ty  reflect.Type
+	}
+	visited := make(map[ref]bool)
+	stack := vs
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !v.IsValid() {
+			continue
+		}
+		if _, ok := teq.formatFor(v); ok {
+			if _, registered := teq.formats[v.Type()]; !registered && !seen[v.Type()] {
+				seen[v.Type()] = true
+				types = append(types, v.Type())
+			}
+			continue
+		}
+		switch v.Kind() {
+		case reflect.Interface:
+			stack = append(stack, v.Elem())
+		case reflect.Pointer, reflect.Map, reflect.Slice:
+			r := ref{v.Pointer(), v.Type()}
+			if v.IsNil() || visited[r] {
+				continue
+			}
+			visited[r] = true
+			switch v.Kind() {
+			case reflect.Pointer:
+				stack = append(stack, v.Elem())
+			case reflect.Map:
+				for it := v.MapRange(); it.Next(); {
+					stack = append(stack, it.Key(), it.Value())
+				}
+			default:
+				for i := 0; i < v.Len(); i++ {
+					stack = append(stack, v.Index(i))
+				}
+			}
+		case reflect.Array:
+			for i := 0; i < v.Len(); i++ {
+				stack = append(stack, v.Index(i))
+			}
+		case reflect.Struct:
+			for i := 0; i < v.NumField(); i++ {
+				stack = append(stack, field(v, i))
+			}
+		}
+	}
+	return types
+}
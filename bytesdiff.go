@@ -0,0 +1,133 @@
+package teq
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+const hexdumpWidth = 16
+
+// bytesLines returns the hexdump diff if the values are byte slices or byte arrays without registered format.
+// With BytesAsText, they are diffed as text if both are valid UTF-8.
+func (teq Teq) bytesLines(ve, va reflect.Value) ([]string, bool) {
+	if !ve.IsValid() || !va.IsValid() || ve.Type() != va.Type() {
+		return nil, false
+	}
+	k := ve.Kind()
+	if (k != reflect.Slice && k != reflect.Array) || ve.Type().Elem().Kind() != reflect.Uint8 {
+		return nil, false
+	}
+	if _, ok := teq.formatOf(ve.Type()); ok {
+		return nil, false
+	}
+	b1, b2 := toBytes(ve), toBytes(va)
+	if teq.BytesAsText && utf8.Valid(b1) && utf8.Valid(b2) {
+		return teq.stringDiff(ve.Type(), string(b1), string(b2)), true
+	}
+	return teq.hexdumpDiff(ve, va, b1, b2), true
+}
+
+func toBytes(v reflect.Value) []byte {
+	b := make([]byte, v.Len())
+	for i := range b {
+		b[i] = byte(v.Index(i).Uint())
+	}
+	return b
+}
+
+// hexdumpDiff renders rows of hexdump, marking rows that differ.
+// Unchanged rows farther than ContextLines from any change are collapsed into ":".
+func (teq Teq) hexdumpDiff(ve, va reflect.Value, b1, b2 []byte) []string {
+	header := fmt.Sprintf("  %s (len %d)", ve.Type(), len(b1))
+	if len(b1) != len(b2) {
+		header = fmt.Sprintf("  %s (len %d vs %d)", ve.Type(), len(b1), len(b2))
+	}
+	if ve.Kind() == reflect.Slice && ve.IsNil() != va.IsNil() {
+		header += fmt.Sprintf(" (nil: %t vs %t)", ve.IsNil(), va.IsNil())
+	}
+
+	n := len(b1)
+	if len(b2) > n {
+		n = len(b2)
+	}
+	rows := (n + hexdumpWidth - 1) / hexdumpWidth
+	changed := make([]bool, rows)
+	for r := range changed {
+		changed[r] = string(hexdumpRow(b1, r)) != string(hexdumpRow(b2, r))
+	}
+	near := func(r int) bool {
+		for d := -teq.ContextLines; d <= teq.ContextLines; d++ {
+			if r+d >= 0 && r+d < rows && changed[r+d] {
+				return true
+			}
+		}
+		return false
+	}
+
+	lines := []string{header}
+	collapsed := false
+	for r := 0; r < rows; r++ {
+		if !near(r) {
+			if !collapsed {
+				lines = append(lines, ":")
+				collapsed = true
+			}
+			continue
+		}
+		collapsed = false
+		if !changed[r] {
+			lines = append(lines, "  "+formatHexdumpRow(r, hexdumpRow(b1, r)))
+			continue
+		}
+		if row := hexdumpRow(b1, r); row != nil {
+			lines = append(lines, "- "+formatHexdumpRow(r, row))
+		}
+		if row := hexdumpRow(b2, r); row != nil {
+			lines = append(lines, "+ "+formatHexdumpRow(r, row))
+		}
+	}
+	return lines
+}
+
+// hexdumpRow returns the r-th row of b, or nil if b is shorter.
+func hexdumpRow(b []byte, r int) []byte {
+	start := r * hexdumpWidth
+	if start >= len(b) {
+		return nil
+	}
+	end := start + hexdumpWidth
+	if end > len(b) {
+		end = len(b)
+	}
+	return b[start:end]
+}
+
+// formatHexdumpRow renders a row like `hexdump -C`.
+func formatHexdumpRow(r int, row []byte) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%08x  ", r*hexdumpWidth)
+	for i := 0; i < hexdumpWidth; i++ {
+		if i < len(row) {
+			fmt.Fprintf(&b, "%02x ", row[i])
+		} else {
+			b.WriteString("   ")
+		}
+		if i == hexdumpWidth/2-1 {
+			b.WriteString(" ")
+		}
+	}
+	b.WriteString(" |")
+	for _, c := range row {
+		if c >= 0x20 && c < 0x7f {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('.')
+		}
+	}
+	// Padding keeps rows the same length so that changed bytes can be pointed at one by one.
+	b.WriteString(strings.Repeat(" ", hexdumpWidth-len(row)))
+	b.WriteString("|")
+	return b.String()
+}
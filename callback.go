@@ -0,0 +1,63 @@
+package teq
+
+import (
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"strings"
+)
+
+// callbackPanic describes a panic raised in a function registered with AddTransform, AddEqual or AddFormat,
+// or in a function polled by Eventually or Consistently.
+type callbackPanic struct {
+	role      string
+	fn        reflect.Type
+	path      *path
+	pathKnown bool
+	args      []reflect.Value
+	recovered any
+	stack     []byte
+}
+
+// callback calls a registered function.
+// A panic in it is returned as *callbackPanic instead of propagating.
+func callback(role string, fn reflect.Value, p *path, args ...reflect.Value) (out reflect.Value, cp *callbackPanic) {
+	defer func() {
+		if r := recover(); r != nil {
+			cp = &callbackPanic{
+				role:      role,
+				fn:        fn.Type(),
+				path:      p,
+				pathKnown: true,
+				args:      args,
+				recovered: r,
+				stack:     debug.Stack(),
+			}
+		}
+	}()
+	return fn.Call(args)[0], nil
+}
+
+func (cp *callbackPanic) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "panic in %s %s", cp.role, cp.fn)
+	if cp.pathKnown {
+		fmt.Fprintf(&b, " at %s", describePath(cp.path))
+	}
+	fmt.Fprintf(&b, ": %v\n", cp.recovered)
+	for i, a := range cp.args {
+		fmt.Fprintf(&b, "argument %d: %s\n", i, sprintValue(a))
+	}
+	b.Write(cp.stack)
+	return b.String()
+}
+
+func sprintValue(v reflect.Value) string {
+	if !v.IsValid() {
+		return "<invalid>"
+	}
+	if v.CanInterface() {
+		return fmt.Sprintf("%#v", v.Interface())
+	}
+	return fmt.Sprintf("%v", v)
+}
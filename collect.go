@@ -0,0 +1,104 @@
+package teq
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// Collector records failures of assertions instead of reporting them immediately,
+// and reports them together in a single message on Check.
+// Collector implements TestingT, so that any assertion of Teq can be recorded, like tq.Len(c, x, 3).
+type Collector struct {
+	teq      Teq
+	t        TestingT
+	failures []failure
+}
+
+type failure struct {
+	// at is the call site of the assertion, like "user_test.go:42".
+	at      string
+	message string
+}
+
+var _ TestingT = &Collector{}
+
+// Collect returns a Collector reporting to t.
+// If t has Cleanup method like *testing.T, Check is called automatically when the test finishes.
+func (teq Teq) Collect(t TestingT) *Collector {
+	c := &Collector{teq: teq, t: t}
+	if cl, ok := t.(cleanuper); ok {
+		cl.Cleanup(func() { c.Check() })
+	}
+	return c
+}
+
+// Equal is like Teq.Equal but records the failure.
+func (c *Collector) Equal(expected, actual any, msgAndArgs ...any) bool {
+	return c.teq.Equal(c, expected, actual, msgAndArgs...)
+}
+
+// NotEqual is like Teq.NotEqual but records the failure.
+func (c *Collector) NotEqual(expected, actual any, msgAndArgs ...any) bool {
+	return c.teq.NotEqual(c, expected, actual, msgAndArgs...)
+}
+
+// Check reports the recorded failures as an error, if any, with their call sites.
+// Reported failures are cleared, so it returns true if nothing failed since the last Check.
+func (c *Collector) Check() bool {
+	c.t.Helper()
+	if len(c.failures) == 0 {
+		return true
+	}
+	lines := []string{fmt.Sprintf("%d failure(s)", len(c.failures))}
+	for i, f := range c.failures {
+		lines = append(lines, fmt.Sprintf("#%d at %s:", i+1, f.at))
+		for _, l := range strings.Split(f.message, "\n") {
+			lines = append(lines, "    "+l)
+		}
+	}
+	c.failures = nil
+	c.t.Error(strings.Join(lines, "\n"))
+	return false
+}
+
+// Helper is a part of TestingT.
+func (c *Collector) Helper() {
+	c.t.Helper()
+}
+
+// Error records a failure. It is a part of TestingT.
+func (c *Collector) Error(args ...interface{}) {
+	c.failures = append(c.failures, failure{at: callSite(), message: fmt.Sprint(args...)})
+}
+
+// Errorf records a failure. It is a part of TestingT.
+func (c *Collector) Errorf(format string, args ...interface{}) {
+	c.failures = append(c.failures, failure{at: callSite(), message: fmt.Sprintf(format, args...)})
+}
+
+// Log logs immediately to the underlying TestingT. It is a part of TestingT.
+func (c *Collector) Log(args ...interface{}) {
+	c.t.Helper()
+	c.t.Log(args...)
+}
+
+var pkgPrefix = reflect.TypeOf(Teq{}).PkgPath() + "."
+
+// callSite returns the location of the first caller outside this package.
+func callSite() string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, pkgPrefix) {
+			return fmt.Sprintf("%s:%d", filepath.Base(f.File), f.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
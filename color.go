@@ -0,0 +1,124 @@
+package teq
+
+import (
+	"os"
+	"strings"
+)
+
+// ColorMode decides whether failure messages are colored with ANSI escape sequences.
+type ColorMode int
+
+const (
+	// ColorAuto colors messages when stdout is a terminal,
+	// unless NO_COLOR or CI environment variable is set.
+	ColorAuto ColorMode = iota
+	// ColorAlways always colors messages.
+	ColorAlways
+	// ColorNever never colors messages.
+	ColorNever
+)
+
+const (
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiRed     = "\x1b[31m"
+	ansiGreen   = "\x1b[32m"
+	ansiInverse = "\x1b[7m"
+	ansiNoInv   = "\x1b[27m"
+)
+
+func (teq Teq) colorEnabled() bool {
+	switch teq.Color {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	if _, ok := os.LookupEnv("CI"); ok {
+		return false
+	}
+	fi, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// colorizeDiff colors removed and added lines of a unified diff,
+// and highlights changed characters between a removed line and the added line paired with it.
+func colorizeDiff(lines []string) []string {
+	out := make([]string, 0, len(lines))
+	var removed, added []string
+	flush := func() {
+		for i := range removed {
+			if i < len(added) {
+				removed[i], added[i] = highlightChange(removed[i], added[i])
+			}
+			out = append(out, ansiRed+removed[i]+ansiReset)
+		}
+		for i := range added {
+			out = append(out, ansiGreen+added[i]+ansiReset)
+		}
+		removed, added = nil, nil
+	}
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "---"):
+			flush()
+			out = append(out, ansiBold+ansiRed+line+ansiReset)
+		case strings.HasPrefix(line, "+++"):
+			flush()
+			out = append(out, ansiBold+ansiGreen+line+ansiReset)
+		case strings.HasPrefix(line, "-"):
+			if len(added) > 0 {
+				flush()
+			}
+			removed = append(removed, line)
+		case strings.HasPrefix(line, "+"):
+			added = append(added, line)
+		default:
+			flush()
+			out = append(out, line)
+		}
+	}
+	flush()
+	return out
+}
+
+// highlightChange highlights the part of the lines between their common prefix and suffix.
+// Lines without anything in common are left as is.
+func highlightChange(a, b string) (string, string) {
+	ra := []rune(a)
+	rb := []rune(b)
+	prefix, suffix, ok := changedSpan(ra, rb)
+	if !ok {
+		return a, b
+	}
+	mark := func(r []rune) string {
+		return string(r[:prefix]) + ansiInverse + string(r[prefix:len(r)-suffix]) + ansiNoInv + string(r[len(r)-suffix:])
+	}
+	return mark(ra), mark(rb)
+}
+
+// changedSpan returns the lengths of the common prefix and suffix of diff lines.
+// The first character, which is the diff marker, is regarded as common.
+// ok is false if the lines have nothing in common but indentation.
+func changedSpan(a, b []rune) (prefix, suffix int, ok bool) {
+	if len(a) == 0 || len(b) == 0 {
+		return 0, 0, false
+	}
+	prefix = 1
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	if strings.TrimSpace(string(a[1:prefix])) == "" && suffix == 0 {
+		return 0, 0, false
+	}
+	return prefix, suffix, true
+}
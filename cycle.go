@@ -0,0 +1,90 @@
+package teq
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// bijection pairs references on both sides under StrictCycles.
+// Each reference must be paired with the same one on the other side wherever it appears,
+// so that cyclic structures are equal only if their shapes are the same,
+// and shared references are equal only if they are shared in the same way.
+type bijection struct {
+	forward  map[reference]pairing
+	backward map[reference]pairing
+}
+
+type reference struct {
+	ptr unsafe.Pointer
+	typ reflect.Type
+	// len distinguishes slices sharing the same underlying array.
+	len int
+}
+
+type pairing struct {
+	other reference
+	// path is where the pair was first met.
+	path *path
+}
+
+func newBijection() *bijection {
+	return &bijection{forward: make(map[reference]pairing), backward: make(map[reference]pairing)}
+}
+
+// referenceOf returns the reference held by v.
+// Empty slices and pointers to zero-size values are not references, because they may share an address without sharing anything.
+func referenceOf(v reflect.Value) (reference, bool) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || v.Type().Elem().Size() == 0 {
+			return reference{}, false
+		}
+		return reference{ptr: v.UnsafePointer(), typ: v.Type()}, true
+	case reflect.Map:
+		if v.IsNil() {
+			return reference{}, false
+		}
+		return reference{ptr: v.UnsafePointer(), typ: v.Type()}, true
+	case reflect.Slice:
+		if v.IsNil() || v.Len() == 0 {
+			return reference{}, false
+		}
+		return reference{ptr: v.UnsafePointer(), typ: v.Type(), len: v.Len()}, true
+	}
+	return reference{}, false
+}
+
+// pair checks that v1 and v2 are paired consistently.
+// done is true if the comparison of them is decided: equal if they are already paired with each other,
+// and not equal if either is paired with another one. Otherwise, they are paired and must be compared as usual.
+// at returns the path of the pair, which is built only if needed.
+func (b *bijection) pair(v1, v2 reflect.Value, at func() *path) (eq, done bool, note string) {
+	r1, ok1 := referenceOf(v1)
+	r2, ok2 := referenceOf(v2)
+	if !ok1 || !ok2 {
+		return false, false, ""
+	}
+	m1, seen1 := b.forward[r1]
+	m2, seen2 := b.backward[r2]
+	if seen1 && seen2 && m1.other == r2 {
+		return true, true, ""
+	}
+	p := at()
+	if seen1 || seen2 {
+		return false, true, fmt.Sprintf(
+			"references are shared differently at %s: expected %s, actual %s",
+			describePath(p), sharedTarget(m1, seen1), sharedTarget(m2, seen2),
+		)
+	}
+	b.forward[r1] = pairing{r2, p}
+	b.backward[r2] = pairing{r1, p}
+	return false, false, ""
+}
+
+func sharedTarget(m pairing, seen bool) string {
+	if !seen {
+		return "a new reference"
+	}
+	return fmt.Sprintf("same reference as %s", describePath(m.path))
+}
@@ -7,6 +7,7 @@ package teq
 import (
 	"bytes"
 	"reflect"
+	"strings"
 	"unsafe"
 )
 
@@ -20,33 +21,260 @@ type visit struct {
 	typ reflect.Type
 }
 
-func (teq Teq) deepValueEqual(
-	v1, v2 reflect.Value,
-	visited map[visit]bool,
-	depth int,
-) bool {
-	if depth > teq.MaxDepth {
-		panic("maximum depth exceeded")
+// comparison holds the state of a single comparison.
+type comparison struct {
+	visited map[visit]bool
+	// exceeded is the first path where MaxDepth was exceeded.
+	exceeded *path
+	// panicked is the panic raised in a registered function, which aborts the comparison.
+	panicked *callbackPanic
+	// refused is the comparison refused by OpaqueFail, which aborts the comparison.
+	refused *refusal
+	// exhaustive makes the comparison go on after a difference is found.
+	exhaustive bool
+	// tracer records each decision if it is not nil.
+	tracer *tracer
+	// collect makes the comparison record differences.
+	collect     bool
+	differences []difference
+	// tolerated records pairs of different types considered equal by NumericEquivalence while collecting.
+	tolerated []difference
+	// pairs is the correspondence of references under StrictCycles.
+	pairs *bijection
+	// notes explain differences which are not obvious from the values, recorded while collecting.
+	notes []string
+}
+
+// difference is a pair of values that differ, recorded while collecting.
+type difference struct {
+	path   *path
+	depth  int
+	v1, v2 reflect.Value
+}
+
+var byteType = reflect.TypeOf(byte(0))
+
+func newComparison() *comparison {
+	return &comparison{visited: make(map[visit]bool)}
+}
+
+// task is a pair of values being compared.
+type task struct {
+	v1, v2 reflect.Value
+	depth  int
+	// plain is true inside transformed values,
+	// where registered equals and transforms are not applied to prevent infinite recursion.
+	plain bool
+	// parent and node are trace nodes, which are set only while tracing.
+	parent *traceNode
+	node   *traceNode
+	w      *walk
+}
+
+// walk is the explicit stack of a comparison.
+// Each frame enumerates the children of a pair of containers lazily,
+// and the steps of the frames on the stack locate the pair being compared.
+type walk struct {
+	frames []frame
+	// pending is the children scheduled by the pair being compared.
+	pending   children
+	scheduled bool
+}
+
+type frame struct {
+	children
+	// depth, plain and node are inherited by the children.
+	depth int
+	plain bool
+	node  *traceNode
+	// s is the step to the child being compared.
+	s step
+	// p is the path of the child being compared, valid if cached.
+	p      *path
+	cached bool
+}
+
+// deepValueEqual compares v1 and v2 with an explicit stack instead of recursion,
+// so that arbitrarily deep structures don't consume goroutine stack.
+// All checks are conjunctive, hence it returns false as soon as any pair differs
+// unless the comparison is exhaustive.
+func (teq Teq) deepValueEqual(v1, v2 reflect.Value, c *comparison) bool {
+	if teq.StrictCycles && c.pairs == nil {
+		c.pairs = newBijection()
+	}
+	w := &walk{frames: []frame{{children: same(v1, v2)}}}
+	var n next = func(ch children) {
+		w.pending, w.scheduled = ch, true
+	}
+	eq := true
+	for len(w.frames) > 0 {
+		f := &w.frames[len(w.frames)-1]
+		v1, v2, s, ok := f.next()
+		if !ok {
+			w.frames = w.frames[:len(w.frames)-1]
+			continue
+		}
+		f.s, f.cached = s, false
+		tk := task{v1: v1, v2: v2, depth: f.depth, plain: f.plain, parent: f.node, w: w}
+		if c.tracer != nil {
+			tk.node = c.tracer.enter(tk.parent, tk.path(), tk.v1)
+		}
+
+		w.scheduled = false
+		if !teq.step(&tk, c, n) {
+			eq = false
+			if c.tracer != nil {
+				c.tracer.fail(tk.node)
+			}
+			if c.collect {
+				c.differences = append(c.differences, difference{tk.path(), tk.depth, tk.v1, tk.v2})
+			}
+			if !c.exhaustive || c.panicked != nil || c.refused != nil {
+				return false
+			}
+			continue
+		}
+		if w.scheduled {
+			w.frames = append(w.frames, frame{children: w.pending, depth: tk.depth + 1, plain: tk.plain, node: tk.node})
+		}
+	}
+	return eq
+}
+
+// path materializes the path of the pair being compared.
+// Most comparisons never need paths, so they are built only on demand and cached on the frames.
+func (w *walk) path() *path {
+	i := len(w.frames) - 1
+	for i >= 0 && !w.frames[i].cached {
+		i--
+	}
+	var p *path
+	if i >= 0 {
+		p = w.frames[i].p
+	}
+	for i++; i < len(w.frames); i++ {
+		f := &w.frames[i]
+		p = p.then(f.s)
+		f.p, f.cached = p, true
+	}
+	return p
+}
+
+func (tk *task) path() *path {
+	return tk.w.path()
+}
+
+// callback calls a registered function for the task, locating a panic in it at the task.
+func (tk *task) callback(role string, fn reflect.Value, args ...reflect.Value) (reflect.Value, *callbackPanic) {
+	out, cp := callback(role, fn, nil, args...)
+	if cp != nil {
+		cp.path = tk.path()
+	}
+	return out, cp
+}
+
+// step compares a single pair of values. It passes the children to be compared next to nx.
+func (teq Teq) step(tk *task, c *comparison, nx next) bool {
+	v1, v2 := tk.v1, tk.v2
+	if teq.MaxDepth > 0 && tk.depth > teq.MaxDepth {
+		if c.exceeded == nil {
+			c.exceeded = tk.path()
+		}
+		tk.note("MaxDepth exceeded")
+		// Under MaxDepthUnknown, the rest of the comparison goes on
+		// so that a definite difference elsewhere is still detected.
+		return teq.OnMaxDepth == MaxDepthUnknown
 	}
 	if !v1.IsValid() || !v2.IsValid() {
+		tk.note("absent on one side")
 		return v1.IsValid() == v2.IsValid()
 	}
+	if teq.ErrorEquality != ErrorStructural && (tk.plain || !teq.registeredError(v1, v2)) {
+		eq, ok, cp := teq.errorEqual(tk)
+		if cp != nil {
+			c.panicked = cp
+			return false
+		}
+		if ok {
+			tk.note("compared as errors")
+			return eq
+		}
+	}
+	if eq, ok := teq.numericEqual(v1, v2); ok {
+		tk.note("compared as numbers: ", v1.Type().String(), " vs ", v2.Type().String())
+		if eq && c.collect && v1.Type() != v2.Type() {
+			c.tolerated = append(c.tolerated, difference{tk.path(), tk.depth, v1, v2})
+		}
+		return eq
+	}
+	if teq.Structural && v1.Type() != v2.Type() {
+		if eq, ok := teq.structuralEq(v1, v2, c, nx); ok {
+			tk.note("compared structurally: ", v1.Type().String(), " vs ", v2.Type().String())
+			return eq
+		}
+	}
 	if v1.Type() != v2.Type() {
+		tk.note("types differ: ", v1.Type().String(), " vs ", v2.Type().String())
 		return false
 	}
 
-	eq, ok := teq.equals[v1.Type()]
-	if ok {
-		return eq(v1, v2)
+	if c.collect && teq.formatted(v1.Type()) {
+		// A formatted value is shown as a whole, so its difference is recorded as a whole.
+		tk.note("formatted, compared as a whole")
+		sub := &comparison{visited: c.visited, pairs: c.pairs}
+		eq := teq.deepValueEqual(v1, v2, sub)
+		c.panicked = sub.panicked
+		c.refused = sub.refused
+		if c.exceeded == nil {
+			c.exceeded = sub.exceeded
+		}
+		return eq
+	}
+
+	if !tk.plain {
+		eq, ok := teq.equals[v1.Type()]
+		if ok {
+			tk.note("equal ", eq.Type().String())
+			out, cp := tk.callback("equal", eq, v1, v2)
+			if cp != nil {
+				c.panicked = cp
+				return false
+			}
+			return out.Bool()
+		}
+
+		tr, ok := teq.transforms[v1.Type()]
+		if ok {
+			tk.note("transform ", tr.Type().String())
+			t1, cp := tk.callback("transform", tr, v1)
+			if cp != nil {
+				c.panicked = cp
+				return false
+			}
+			t2, cp := tk.callback("transform", tr, v2)
+			if cp != nil {
+				c.panicked = cp
+				return false
+			}
+			// Transformed values are compared at the same depth.
+			tk.v1, tk.v2, tk.plain = t1, t2, true
+			return teq.step(tk, c, nx)
+		}
 	}
 
-	tr, ok := teq.transforms[v1.Type()]
-	if ok {
-		t1 := tr(v1)
-		t2 := tr(v2)
-		newTeq := New()
-		newTeq.MaxDepth = teq.MaxDepth
-		return newTeq.deepValueEqual(t1, t2, visited, depth)
+	if teq.StrictCycles {
+		eq, done, note := c.pairs.pair(v1, v2, tk.path)
+		if done {
+			if note != "" {
+				tk.note(note)
+				if c.collect {
+					c.notes = append(c.notes, note)
+				}
+			} else {
+				tk.note("already paired")
+			}
+			return eq
+		}
 	}
 
 	if hard(v1.Kind()) {
@@ -56,6 +284,7 @@ func (teq Teq) deepValueEqual(
 
 			// Short circuit
 			if uintptr(addr1) == uintptr(addr2) {
+				tk.note("same address")
 				return true
 			}
 			if uintptr(addr1) > uintptr(addr2) {
@@ -66,36 +295,171 @@ func (teq Teq) deepValueEqual(
 			// Short circuit if references are already seen.
 			typ := v1.Type()
 			v := visit{addr1, addr2, typ}
-			if visited[v] {
+			if c.visited[v] {
+				tk.note("already visited, assumed equal")
 				return true
 			}
 
 			// Remember for later.
-			visited[v] = true
+			c.visited[v] = true
 		}
 	}
 
+	if opaque(v1.Kind()) {
+		return teq.opaqueEq(tk, c)
+	}
+
+	// Byte arrays are compared as a whole like byte slices, so that their difference is reported as a hexdump.
+	// Arrays of other byte-sized types, or of bytes with a registered function, are compared element by element.
+	if v1.Kind() == reflect.Array && v1.Type().Elem() == byteType && !teq.registered(byteType) {
+		tk.note("byte array")
+		return byteArrayEq(v1, v2)
+	}
+
 	eqFn, ok := eqs[v1.Kind()]
 	if !ok {
 		panic("equality is not defined for " + v1.Type().String())
 	}
-	var n next = func(v1, v2 reflect.Value) bool {
-		return teq.deepValueEqual(v1, v2, visited, depth+1)
+	tk.note(v1.Kind().String())
+	return eqFn(v1, v2, c, nx)
+}
+
+// acrossTypes tells whether values of different types may be equal by the options of Teq.
+func (teq Teq) acrossTypes(v1, v2 reflect.Value) bool {
+	if _, ok := teq.numericEqual(v1, v2); ok {
+		return true
+	}
+	if teq.ErrorEquality != ErrorStructural && isError(v1) && isError(v2) {
+		return true
 	}
-	return eqFn(v1, v2, n)
+	return teq.Structural
 }
 
-type next func(v1, v2 reflect.Value) bool
+// formatted tells whether values of the type are rendered by a format function.
+func (teq Teq) formatted(ty reflect.Type) bool {
+	_, ok := teq.formatOf(ty)
+	return ok
+}
+
+// registered tells whether an equal or transform function is registered for the type.
+func (teq Teq) registered(ty reflect.Type) bool {
+	_, eq := teq.equals[ty]
+	_, tr := teq.transforms[ty]
+	return eq || tr
+}
+
+// note records a decision on the trace node. It does nothing unless tracing.
+func (tk *task) note(decision ...string) {
+	if tk.node != nil {
+		tk.node.decisions = append(tk.node.decisions, strings.Join(decision, ""))
+	}
+}
+
+// next schedules the children of the pair being compared.
+type next func(ch children)
+
+// children enumerates pairs of child values lazily, so that a large container is not expanded at once.
+type children struct {
+	kind   childrenKind
+	v1, v2 reflect.Value
+	i, n   int
+	// iter iterates over the entries of v1 for entryChildren.
+	iter *reflect.MapIter
+	list []child
+}
+
+type childrenKind int
+
+const (
+	// sameChild is v1 and v2 themselves at the same path, like the elements of pointers.
+	sameChild childrenKind = iota
+	indexChildren
+	fieldChildren
+	// entryChildren are the values of v1 and the values of v2 for the same keys.
+	entryChildren
+	listChildren
+)
+
+type child struct {
+	v1, v2 reflect.Value
+	s      step
+}
+
+func same(v1, v2 reflect.Value) children {
+	return children{kind: sameChild, v1: v1, v2: v2, n: 1}
+}
+
+func elements(v1, v2 reflect.Value) children {
+	return children{kind: indexChildren, v1: v1, v2: v2, n: v1.Len()}
+}
+
+func fields(v1, v2 reflect.Value) children {
+	return children{kind: fieldChildren, v1: v1, v2: v2, n: v1.NumField()}
+}
+
+func listed(cs []child) children {
+	return children{kind: listChildren, list: cs, n: len(cs)}
+}
+
+// next returns the next pair of children and the step to them. ok is false if there are no more children.
+func (ch *children) next() (v1, v2 reflect.Value, s step, ok bool) {
+	if ch.kind == entryChildren {
+		if !ch.iter.Next() {
+			return reflect.Value{}, reflect.Value{}, step{}, false
+		}
+		k := ch.iter.Key()
+		return ch.iter.Value(), ch.v2.MapIndex(k), step{kind: keyStep, mapKey: k}, true
+	}
+	if ch.i >= ch.n {
+		return reflect.Value{}, reflect.Value{}, step{}, false
+	}
+	i := ch.i
+	ch.i++
+	switch ch.kind {
+	case indexChildren:
+		return ch.v1.Index(i), ch.v2.Index(i), step{kind: indexStep, idx: i}, true
+	case fieldChildren:
+		return field(ch.v1, i), field(ch.v2, i), step{kind: fieldStep, idx: i, of: ch.v1.Type()}, true
+	case listChildren:
+		c := ch.list[i]
+		return c.v1, c.v2, c.s, true
+	}
+	return ch.v1, ch.v2, step{}, true
+}
+
+// mapChildren pairs the values of maps by keys. ok is false if the maps differ without comparing values.
+// An exhaustive comparison, which is for reports and traces, pairs them in the order of keys,
+// and also pairs keys present only in v2 with invalid values so that each of them is recognized as a difference at its own path.
+func mapChildren(v1, v2 reflect.Value, c *comparison) (ch children, ok bool) {
+	if !c.exhaustive {
+		if v1.Len() != v2.Len() {
+			return children{}, false
+		}
+		return children{kind: entryChildren, v2: v2, iter: v1.MapRange()}, true
+	}
+	// Values are taken from the entries rather than looked up by keys,
+	// since a key such as NaN can't be looked up even in its own map.
+	var cs []child
+	keys, values := sortedEntries(v1)
+	for i, k := range keys {
+		cs = append(cs, child{values[i], v2.MapIndex(k), step{kind: keyStep, mapKey: k}})
+	}
+	keys, values = sortedEntries(v2)
+	for i, k := range keys {
+		if !v1.MapIndex(k).IsValid() {
+			cs = append(cs, child{reflect.Value{}, values[i], step{kind: keyStep, mapKey: k}})
+		}
+	}
+	return listed(cs), true
+}
 
-var eqs = map[reflect.Kind]func(v1, v2 reflect.Value, nx next) bool{
+var eqs = map[reflect.Kind]func(v1, v2 reflect.Value, c *comparison, nx next) bool{
 	reflect.Array:      arrayEq,
 	reflect.Slice:      sliceEq,
-	reflect.Chan:       chanEq,
 	reflect.Interface:  interfaceEq,
 	reflect.Pointer:    pointerEq,
 	reflect.Struct:     structEq,
 	reflect.Map:        mapEq,
-	reflect.Func:       funcEq,
 	reflect.Int:        intEq,
 	reflect.Int8:       intEq,
 	reflect.Int16:      intEq,
@@ -123,16 +487,21 @@ func hard(k reflect.Kind) bool {
 	return false
 }
 
-func arrayEq(v1, v2 reflect.Value, nx next) bool {
+func arrayEq(v1, v2 reflect.Value, _ *comparison, nx next) bool {
+	nx(elements(v1, v2))
+	return true
+}
+
+func byteArrayEq(v1, v2 reflect.Value) bool {
 	for i := 0; i < v1.Len(); i++ {
-		if !nx(v1.Index(i), v2.Index(i)) {
+		if v1.Index(i).Uint() != v2.Index(i).Uint() {
 			return false
 		}
 	}
 	return true
 }
 
-func sliceEq(v1, v2 reflect.Value, nx next) bool {
+func sliceEq(v1, v2 reflect.Value, _ *comparison, nx next) bool {
 	if v1.IsNil() != v2.IsNil() {
 		return false
 	}
@@ -146,78 +515,49 @@ func sliceEq(v1, v2 reflect.Value, nx next) bool {
 	if v1.Type().Elem().Kind() == reflect.Uint8 {
 		return bytes.Equal(v1.Bytes(), v2.Bytes())
 	}
-	for i := 0; i < v1.Len(); i++ {
-		if !nx(v1.Index(i), v2.Index(i)) {
-			return false
-		}
-	}
+	nx(elements(v1, v2))
 	return true
 }
 
-func chanEq(v1, v2 reflect.Value, _ next) bool {
-	if v1.CanInterface() && v2.CanInterface() {
-		return v1.Interface() == v2.Interface()
-	}
-	if v1.CanInterface() != v2.CanInterface() {
-		return false
-	}
-	panic("failed to compare channels")
-}
-
-func interfaceEq(v1, v2 reflect.Value, nx next) bool {
+func interfaceEq(v1, v2 reflect.Value, _ *comparison, nx next) bool {
 	if v1.IsNil() || v2.IsNil() {
 		return v1.IsNil() == v2.IsNil()
 	}
-	return nx(v1.Elem(), v2.Elem())
+	nx(same(v1.Elem(), v2.Elem()))
+	return true
 }
 
-func pointerEq(v1, v2 reflect.Value, nx next) bool {
+func pointerEq(v1, v2 reflect.Value, _ *comparison, nx next) bool {
 	if v1.UnsafePointer() == v2.UnsafePointer() {
 		return true
 	}
-	return nx(v1.Elem(), v2.Elem())
+	nx(same(v1.Elem(), v2.Elem()))
+	return true
 }
 
-func structEq(v1, v2 reflect.Value, nx next) bool {
-	for i, n := 0, v1.NumField(); i < n; i++ {
-		if !nx(field(v1, i), field(v2, i)) {
-			return false
-		}
-	}
+func structEq(v1, v2 reflect.Value, _ *comparison, nx next) bool {
+	nx(fields(v1, v2))
 	return true
 }
 
-func mapEq(v1, v2 reflect.Value, nx next) bool {
+func mapEq(v1, v2 reflect.Value, c *comparison, nx next) bool {
 	if v1.IsNil() != v2.IsNil() {
 		return false
 	}
-	if v1.Len() != v2.Len() {
-		return false
-	}
 	if v1.UnsafePointer() == v2.UnsafePointer() {
 		return true
 	}
-	for _, k := range v1.MapKeys() {
-		val1 := v1.MapIndex(k)
-		val2 := v2.MapIndex(k)
-		if !val1.IsValid() || !val2.IsValid() || !nx(val1, val2) {
-			return false
-		}
+	ch, ok := mapChildren(v1, v2, c)
+	if !ok {
+		return false
 	}
+	nx(ch)
 	return true
 }
 
-func funcEq(v1, v2 reflect.Value, _ next) bool {
-	if v1.IsNil() && v2.IsNil() {
-		return true
-	}
-	// Can't do better than this:
-	return false
-}
-
-func intEq(v1, v2 reflect.Value, _ next) bool     { return v1.Int() == v2.Int() }
-func uintEq(v1, v2 reflect.Value, _ next) bool    { return v1.Uint() == v2.Uint() }
-func stringEq(v1, v2 reflect.Value, _ next) bool  { return v1.String() == v2.String() }
-func boolEq(v1, v2 reflect.Value, _ next) bool    { return v1.Bool() == v2.Bool() }
-func floatEq(v1, v2 reflect.Value, _ next) bool   { return v1.Float() == v2.Float() }
-func complexEq(v1, v2 reflect.Value, _ next) bool { return v1.Complex() == v2.Complex() }
+func intEq(v1, v2 reflect.Value, _ *comparison, _ next) bool     { return v1.Int() == v2.Int() }
+func uintEq(v1, v2 reflect.Value, _ *comparison, _ next) bool    { return v1.Uint() == v2.Uint() }
+func stringEq(v1, v2 reflect.Value, _ *comparison, _ next) bool  { return v1.String() == v2.String() }
+func boolEq(v1, v2 reflect.Value, _ *comparison, _ next) bool    { return v1.Bool() == v2.Bool() }
+func floatEq(v1, v2 reflect.Value, _ *comparison, _ next) bool   { return v1.Float() == v2.Float() }
+func complexEq(v1, v2 reflect.Value, _ *comparison, _ next) bool { return v1.Complex() == v2.Complex() }
@@ -0,0 +1,160 @@
+package teq
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrorMode decides how values implementing error are compared.
+type ErrorMode int
+
+const (
+	// ErrorStructural compares errors as any other values, including their unexported internals.
+	ErrorStructural ErrorMode = iota
+	// ErrorChain considers the actual error equal to the expected one if errors.Is(actual, expected) holds.
+	ErrorChain
+	// ErrorMessage compares errors by their messages.
+	ErrorMessage
+	// ErrorTypeMessage compares errors by their dynamic types and messages.
+	ErrorTypeMessage
+)
+
+var (
+	errorsIs     = reflect.ValueOf(errors.Is)
+	errorMessage = reflect.ValueOf(func(err error) string { return err.Error() })
+)
+
+// errorEqual compares the pair of the task as errors according to ErrorEquality.
+// ok is false if they are not compared as errors, i.e. under ErrorStructural or if either is not an error.
+func (teq Teq) errorEqual(tk *task) (eq, ok bool, cp *callbackPanic) {
+	v1, v2 := tk.v1, tk.v2
+	if teq.ErrorEquality == ErrorStructural ||
+		!v1.Type().Implements(errorType) || !v2.Type().Implements(errorType) {
+		return false, false, nil
+	}
+	e1, e2 := asError(v1), asError(v2)
+	if !e1.IsValid() || !e2.IsValid() {
+		return e1.IsValid() == e2.IsValid(), true, nil
+	}
+	switch teq.ErrorEquality {
+	case ErrorChain:
+		out, cp := tk.callback("error", errorsIs, e2, e1)
+		if cp != nil {
+			return false, true, cp
+		}
+		return out.Bool(), true, nil
+	case ErrorTypeMessage:
+		if e1.Elem().Type() != e2.Elem().Type() {
+			return false, true, nil
+		}
+	}
+	m1, cp := tk.callback("error", errorMessage, e1)
+	if cp != nil {
+		return false, true, cp
+	}
+	m2, cp := tk.callback("error", errorMessage, e2)
+	if cp != nil {
+		return false, true, cp
+	}
+	return m1.String() == m2.String(), true, nil
+}
+
+// registeredError tells whether a registered function applies to the pair.
+// Interfaces are looked through, so that an equal for *MyError applies to a field declared as error.
+func (teq Teq) registeredError(v1, v2 reflect.Value) bool {
+	t1, t2 := dynamicType(v1), dynamicType(v2)
+	return t1 == t2 && teq.registered(t1)
+}
+
+// dynamicType returns the type of the value in v if v is a non-nil interface, or the type of v otherwise.
+func dynamicType(v reflect.Value) reflect.Type {
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		return v.Elem().Type()
+	}
+	return v.Type()
+}
+
+// asError returns v as a value of the error interface, or an invalid value if v is nil.
+func asError(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+	}
+	e := reflect.New(errorType).Elem()
+	e.Set(v)
+	return e
+}
+
+// errorLines renders the unwrap chains of errors as a line diff unless ErrorEquality is ErrorStructural.
+// Errors with registered formats, and errors whose chains are rendered identically, are left to the ordinary diff.
+func (teq Teq) errorLines(ve, va reflect.Value) ([]string, bool) {
+	if teq.ErrorEquality == ErrorStructural || !isError(ve) || !isError(va) {
+		return nil, false
+	}
+	if _, ok := teq.formatOf(ve.Type()); ok {
+		return nil, false
+	}
+	if _, ok := teq.formatOf(va.Type()); ok {
+		return nil, false
+	}
+	c1, c2 := errorChain(asError(ve).Interface().(error)), errorChain(asError(va).Interface().(error))
+	if strings.Join(c1, "\n") == strings.Join(c2, "\n") {
+		return nil, false
+	}
+	lines := []string{"  error chain:"}
+	for _, l := range lineDiff(c1, c2) {
+		switch l.op {
+		case lineEqual:
+			lines = append(lines, "    "+l.text)
+		case lineRemoved:
+			lines = append(lines, "-   "+l.text)
+		case lineAdded:
+			lines = append(lines, "+   "+l.text)
+		}
+	}
+	return lines, true
+}
+
+// isError tells whether v is a non-nil value of a type implementing error.
+func isError(v reflect.Value) bool {
+	return v.IsValid() && v.Type().Implements(errorType) && asError(v).IsValid()
+}
+
+// errorChain renders err and the errors it wraps, one per line and indented by depth.
+// A panic in Error propagates as *callbackPanic.
+func errorChain(err error) []string {
+	var lines []string
+	type entry struct {
+		err   error
+		depth int
+	}
+	stack := []entry{{err, 0}}
+	for len(stack) > 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		out, cp := callback("error", errorMessage, nil, reflect.ValueOf(&e.err).Elem())
+		if cp != nil {
+			cp.pathKnown = false
+			panic(cp)
+		}
+		lines = append(lines, fmt.Sprintf("%s%T(%q)", strings.Repeat("  ", e.depth), e.err, out.String()))
+		switch u := e.err.(type) {
+		case interface{ Unwrap() error }:
+			if w := u.Unwrap(); w != nil {
+				stack = append(stack, entry{w, e.depth + 1})
+			}
+		case interface{ Unwrap() []error }:
+			ws := u.Unwrap()
+			for i := len(ws) - 1; i >= 0; i-- {
+				if ws[i] != nil {
+					stack = append(stack, entry{ws[i], e.depth + 1})
+				}
+			}
+		}
+	}
+	return lines
+}
@@ -0,0 +1,46 @@
+package teq
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// SignatureError is returned when a function with invalid signature is passed to TryAddTransform, TryAddFormat or TryAddEqual.
+type SignatureError struct {
+	// Role is one of "transform", "format" and "equal".
+	Role string
+	// Func is the type of the passed value. It is nil if nil is passed.
+	Func reflect.Type
+	// Reason describes what is wrong with the signature.
+	Reason string
+}
+
+func signatureError(role string, ty reflect.Type, reason string) *SignatureError {
+	return &SignatureError{Role: role, Func: ty, Reason: reason}
+}
+
+func (e *SignatureError) Error() string {
+	if e.Func == nil {
+		return fmt.Sprintf("%s, got nil", e.Reason)
+	}
+	return fmt.Sprintf("%s, got %s", e.Reason, e.Func)
+}
+
+// ConflictError is returned by TryAddTransform, TryAddFormat or TryAddEqual
+// when a registration conflicts with another registration for the same type.
+// For example, an equal function and a transform function for the same type conflict
+// because the equal function would silently take precedence,
+// and two different format functions for the same type conflict because the latter would silently replace the former.
+// Registering the same function again is not a conflict.
+type ConflictError struct {
+	// Type is the type for which both functions are registered.
+	Type reflect.Type
+	// Role is the role of the function being registered.
+	Role string
+	// Registered is the role of the function already registered.
+	Registered string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s for %s conflicts with %s registered for the same type", e.Role, e.Type, e.Registered)
+}
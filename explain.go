@@ -0,0 +1,110 @@
+package teq
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Explain compares expected and actual, and returns a tree describing each decision made by the comparison.
+// Each line shows the path, the type, the decisions such as the kind handler, registered equals and transforms,
+// and whether the pair is equal.
+// Unlike Equal, the comparison goes on after a difference is found so that the whole tree is shown.
+func (teq Teq) Explain(expected, actual any) string {
+	if expected == nil || actual == nil {
+		return fmt.Sprintf("(root): nil comparison -> %s", verdict(expected == actual))
+	}
+	v1 := reflect.ValueOf(expected)
+	v2 := reflect.ValueOf(actual)
+	if v1.Type() != v2.Type() && !teq.acrossTypes(v1, v2) {
+		return fmt.Sprintf("(root): types differ: %s vs %s -> %s", v1.Type(), v2.Type(), verdict(false))
+	}
+	c := newComparison()
+	c.exhaustive = true
+	c.tracer = &tracer{}
+	teq.deepValueEqual(v1, v2, c)
+	out := c.tracer.String()
+	if c.panicked != nil {
+		out += "\n" + c.panicked.String()
+	}
+	if c.refused != nil {
+		out += "\n" + c.refused.String()
+	}
+	return out
+}
+
+// tracer builds a tree of decisions made during a comparison.
+type tracer struct {
+	root *traceNode
+}
+
+type traceNode struct {
+	parent    *traceNode
+	children  []*traceNode
+	path      *path
+	typ       reflect.Type
+	decisions []string
+	failed    bool
+}
+
+func (tr *tracer) enter(parent *traceNode, p *path, v reflect.Value) *traceNode {
+	n := &traceNode{parent: parent, path: p}
+	if v.IsValid() {
+		n.typ = v.Type()
+	}
+	if parent == nil {
+		tr.root = n
+	} else {
+		parent.children = append(parent.children, n)
+	}
+	return n
+}
+
+// fail marks the node and its ancestors as not equal.
+func (tr *tracer) fail(n *traceNode) {
+	for ; n != nil && !n.failed; n = n.parent {
+		n.failed = true
+	}
+}
+
+// String renders the tree. It doesn't recurse since the tree can be as deep as the compared values.
+func (tr *tracer) String() string {
+	if tr.root == nil {
+		return ""
+	}
+	type item struct {
+		node   *traceNode
+		indent int
+	}
+	var lines []string
+	stack := []item{{tr.root, 0}}
+	for len(stack) > 0 {
+		it := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		lines = append(lines, strings.Repeat("  ", it.indent)+it.node.String())
+		for i := len(it.node.children) - 1; i >= 0; i-- {
+			stack = append(stack, item{it.node.children[i], it.indent + 1})
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (n *traceNode) String() string {
+	var b strings.Builder
+	b.WriteString(describePath(n.path))
+	if n.typ != nil {
+		fmt.Fprintf(&b, " %s", n.typ)
+	}
+	if len(n.decisions) > 0 {
+		fmt.Fprintf(&b, ": %s", strings.Join(n.decisions, ", "))
+	}
+	fmt.Fprintf(&b, " -> %s", verdict(!n.failed))
+	return b.String()
+}
+
+func verdict(eq bool) string {
+	if eq {
+		return "equal"
+	}
+	return "not equal"
+}
@@ -2,51 +2,315 @@ package teq
 
 import (
 	"fmt"
+	"math"
+	"math/big"
 	"reflect"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/seiyab/akashi"
 )
 
-func (teq Teq) report(expected, actual any) string {
-	simple := fmt.Sprintf("expected %v, got %v", expected, actual)
+func (teq Teq) report(expected, actual any, color bool) (msg string) {
+	defer recoverCallbackPanic(&msg)
+	rp := teq.Reporter
+	if rp == nil {
+		rp = UnifiedReporter{}
+	}
+	return rp.Report(Report{Expected: expected, Actual: actual, teq: teq, color: color})
+}
+
+// notEqualReport is the failure message of NotEqual.
+func (teq Teq) notEqualReport(expected, actual any) (msg string) {
+	defer recoverCallbackPanic(&msg)
+	if teq.ExpectedLabel == "" && teq.ActualLabel == "" {
+		return fmt.Sprintf("expected %s != %s", teq.formatAny(expected), teq.formatAny(actual))
+	}
+	el, al := teq.labels()
+	return fmt.Sprintf("%s %s == %s %s, expected them to differ", el, teq.formatAny(expected), al, teq.formatAny(actual))
+}
+
+// recoverCallbackPanic replaces the message with the panic in a registered function, if any.
+func recoverCallbackPanic(msg *string) {
+	if r := recover(); r != nil {
+		cp, ok := r.(*callbackPanic)
+		if !ok {
+			panic(r)
+		}
+		*msg = cp.String()
+	}
+}
+
+func (teq Teq) unifiedReport(expected, actual any, color bool) string {
 	if expected == nil || actual == nil {
-		return simple
+		return teq.simpleMessage(teq.formatAny(expected), teq.formatAny(actual))
 	}
 	ve := reflect.ValueOf(expected)
 	va := reflect.ValueOf(actual)
-	if ve.Type() != va.Type() {
-		return simple
+	errs := teq.ErrorEquality != ErrorStructural && isError(ve) && isError(va)
+	if _, ok := teq.numericEqual(ve, va); ok {
+		// The types were tolerated by NumericEquivalence, so the values are what differ.
+		lines := []string{teq.scalarReport(ve, va, color)}
+		lines = append(lines, teq.comparisonNotes(teq.exhaustiveComparison(ve, va))...)
+		return teq.limitSize(strings.Join(lines, "\n"))
+	}
+	if ve.Type() != va.Type() && !errs {
+		if teq.Structural {
+			// akashi can't diff values of different types.
+			return PathReporter{}.Report(Report{Expected: expected, Actual: actual, teq: teq, color: color})
+		}
+		return teq.limitSize(teq.typeMismatchReport(ve, va))
 	}
 	k := ve.Kind()
-	_, ok := teq.formats[ve.Type()]
-	if !ok {
+	_, ok := teq.formatOf(ve.Type())
+	if !ok && !errs {
 		if k != reflect.Struct &&
 			k != reflect.Map &&
 			k != reflect.Slice &&
 			k != reflect.Array &&
 			k != reflect.String &&
 			k != reflect.Pointer {
-			return simple
+			return teq.limitSize(teq.scalarReport(ve, va, color))
 		}
 		es, ok1 := expected.(string)
 		as, ok2 := actual.(string)
 		if ok1 && ok2 && !strings.Contains(es, "\n") && !strings.Contains(as, "\n") {
-			return simple
+			if hasInvisible(es) || hasInvisible(as) {
+				return teq.simpleMessage(strconv.Quote(es), strconv.Quote(as))
+			}
+			return teq.simpleMessage(es, as)
 		}
 	}
 
-	head := []string{
+	head := teq.diffHead()
+	body, isText := teq.textLines(ve, va)
+	if !isText {
+		body = strings.Split(teq.diff(expected, actual), "\n")
+	}
+	body = teq.limitDiffLines(body)
+	if !color && isText {
+		body = markChanges(body)
+	}
+	c := teq.exhaustiveComparison(ve, va)
+	lines := append(head, body...)
+	if !isText {
+		lines = append(lines, teq.nestedBytesLines(c, color)...)
+	}
+	if color {
+		lines = colorizeDiff(lines)
+	}
+	lines = append(lines, teq.comparisonNotes(c)...)
+	return teq.limitSize(strings.Join(lines, "\n"))
+}
+
+// exhaustiveComparison compares the values thoroughly, collecting every difference.
+func (teq Teq) exhaustiveComparison(ve, va reflect.Value) *comparison {
+	c := newComparison()
+	c.exhaustive = true
+	c.collect = true
+	teq.deepValueEqual(ve, va, c)
+	return c
+}
+
+// nestedBytesLines renders differences of byte slices and byte arrays inside other values as hexdump diffs,
+// since the diff by akashi shows them as lists of decimal numbers.
+func (teq Teq) nestedBytesLines(c *comparison, color bool) []string {
+	var lines []string
+	for _, d := range c.differences {
+		if d.path == nil {
+			continue
+		}
+		section, ok := teq.bytesLines(d.v1, d.v2)
+		if !ok {
+			continue
+		}
+		if !color {
+			section = markChanges(section)
+		}
+		lines = append(lines, "bytes at "+describePath(d.path)+":")
+		lines = append(lines, section...)
+	}
+	return lines
+}
+
+func (teq Teq) diffHead() []string {
+	el, al := teq.labels()
+	return []string{
 		"not equal",
 		"differences:",
-		"--- expected",
-		"+++ actual",
+		"--- " + el,
+		"+++ " + al,
+	}
+}
+
+// simpleMessage reports values in one line.
+func (teq Teq) simpleMessage(expected, actual string) string {
+	if teq.ExpectedLabel == "" && teq.ActualLabel == "" {
+		return fmt.Sprintf("expected %s, got %s", expected, actual)
+	}
+	el, al := teq.labels()
+	return fmt.Sprintf("%s %s, %s %s", el, expected, al, actual)
+}
+
+// textLines returns the diff rendered by teq itself rather than akashi, which is for strings, errors and bytes.
+func (teq Teq) textLines(ve, va reflect.Value) ([]string, bool) {
+	if lines, ok := teq.stringLines(ve, va); ok {
+		return lines, true
+	}
+	if lines, ok := teq.errorLines(ve, va); ok {
+		return lines, true
+	}
+	return teq.bytesLines(ve, va)
+}
+
+// stringLines returns the line diff if the values are multi-line strings without registered format.
+func (teq Teq) stringLines(ve, va reflect.Value) ([]string, bool) {
+	if !ve.IsValid() || !va.IsValid() || ve.Type() != va.Type() || ve.Kind() != reflect.String {
+		return nil, false
+	}
+	if _, ok := teq.formatOf(ve.Type()); ok {
+		return nil, false
+	}
+	es, as := ve.String(), va.String()
+	if !strings.Contains(es, "\n") && !strings.Contains(as, "\n") {
+		return nil, false
+	}
+	return teq.stringDiff(ve.Type(), es, as), true
+}
+
+// hasInvisible tells whether s contains control characters or invalid UTF-8 bytes.
+func hasInvisible(s string) bool {
+	if !utf8.ValidString(s) {
+		return true
+	}
+	for _, r := range s {
+		if unicode.IsControl(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// scalarReport reports scalars in the same style as the diff, with their types and full precision.
+func (teq Teq) scalarReport(ve, va reflect.Value, color bool) string {
+	lines := append(teq.diffHead(),
+		"- "+teq.formatTyped(ve),
+		"+ "+teq.formatTyped(va),
+	)
+	if color {
+		lines = colorizeDiff(lines)
+	}
+	if d, ok := delta(ve, va); ok {
+		lines = append(lines, "delta: "+d)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatAny renders a value given to an assertion with formatTyped.
+func (teq Teq) formatAny(x any) string {
+	if x == nil {
+		return "nil"
+	}
+	return teq.formatTyped(reflect.ValueOf(x))
+}
+
+// formatTyped renders a value in one line with its type, like `int64(1)`.
+// Registered formats are applied.
+func (teq Teq) formatTyped(v reflect.Value) string {
+	s := teq.formatValue(v)
+	if _, ok := teq.formatFor(v); ok {
+		return s
+	}
+	switch v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer, reflect.Interface,
+		reflect.Struct, reflect.Slice, reflect.Array, reflect.Map, reflect.Pointer:
+		// The printer already tells the type of them.
+		return s
+	case reflect.Complex64, reflect.Complex128:
+		// strconv.FormatComplex already encloses the value in parentheses.
+		return v.Type().String() + s
+	}
+	return fmt.Sprintf("%s(%s)", v.Type(), s)
+}
+
+// delta returns actual - expected for numbers of the same type.
+// Integers are subtracted exactly without overflow.
+func delta(ve, va reflect.Value) (string, bool) {
+	if ve.Type() != va.Type() {
+		return "", false
+	}
+	switch ve.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		d := new(big.Int).Sub(big.NewInt(va.Int()), big.NewInt(ve.Int()))
+		return signed(d.String()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		d := new(big.Int).Sub(new(big.Int).SetUint64(va.Uint()), new(big.Int).SetUint64(ve.Uint()))
+		return signed(d.String()), true
+	case reflect.Float32, reflect.Float64:
+		d := va.Float() - ve.Float()
+		if math.IsNaN(d) {
+			return "", false
+		}
+		return signed(strconv.FormatFloat(d, 'g', -1, ve.Type().Bits())), true
+	}
+	return "", false
+}
+
+func signed(s string) string {
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		return s
+	}
+	return "+" + s
+}
+
+func (teq Teq) typeMismatchReport(ve, va reflect.Value) string {
+	el, al := teq.labels()
+	return strings.Join([]string{
+		"type mismatch",
+		"--- " + el + ": " + typeName(ve.Type()),
+		"+++ " + al + ": " + typeName(va.Type()),
+		"- " + teq.formatTyped(ve),
+		"+ " + teq.formatTyped(va),
+	}, "\n")
+}
+
+const numericTypesNote = "numeric types differ at %s: %s vs %s, compared by value"
+
+// comparisonNotes explains what the diff doesn't tell clearly:
+// where dynamic types of nested interfaces diverge, where NumericEquivalence tolerated the divergence,
+// and where shapes of cycles differ under StrictCycles.
+func (teq Teq) comparisonNotes(c *comparison) []string {
+	var notes []string
+	for _, d := range c.differences {
+		if !d.v1.IsValid() || !d.v2.IsValid() || d.v1.Type() == d.v2.Type() {
+			continue
+		}
+		format := "dynamic types differ at %s: %s vs %s"
+		if _, ok := teq.numericEqual(d.v1, d.v2); ok {
+			format = numericTypesNote
+		}
+		notes = append(notes, fmt.Sprintf(format, describePath(d.path), typeName(d.v1.Type()), typeName(d.v2.Type())))
+	}
+	for _, d := range c.tolerated {
+		notes = append(notes, fmt.Sprintf(numericTypesNote, describePath(d.path), typeName(d.v1.Type()), typeName(d.v2.Type())))
+	}
+	return append(notes, c.notes...)
+}
+
+func (teq Teq) diff(expected, actual any) string {
+	if lines, ok := teq.textLines(reflect.ValueOf(expected), reflect.ValueOf(actual)); ok {
+		return strings.Join(lines, "\n")
 	}
 	options := []akashi.Option{}
 	for _, f := range teq.formats {
-		options = append(options, akashi.WithFormat(f))
+		options = append(options, akashi.WithFormat(teq.akashiFormat(f)))
+	}
+	for _, ty := range teq.autoFormatTypes(reflect.ValueOf(expected), reflect.ValueOf(actual)) {
+		f, _ := teq.formatOf(ty)
+		options = append(options, akashi.WithFormat(teq.akashiFormat(f)))
 	}
 	options = append(options, akashi.WithReflectEqual(teq.reflectEqual))
-	diff := akashi.DiffString(expected, actual, options...)
-	return strings.Join(append(head, diff), "\n")
+	return akashi.DiffString(expected, actual, options...)
 }
@@ -0,0 +1,67 @@
+package teq
+
+import (
+	"reflect"
+)
+
+// FormatContext tells a format function where the value is, and lets it format child values.
+// It is passed to a format function with the signature func(teq.FormatContext, T) string.
+type FormatContext struct {
+	// Path locates the value from the root, such as `.Items[2]`.
+	// It is empty at the root, and also when the location is unknown.
+	// Path and Depth are supported only by PathReporter and JSONReporter.
+	// They are unsupported in diffs rendered by akashi, which is the case of the default UnifiedReporter and SideBySideReporter:
+	// there, the location is always unknown, including for child values formatted with Format.
+	Path string
+	// Depth is the nesting depth of the value from the root. It is 0 when the location is unknown.
+	Depth int
+
+	format func(v any) string
+}
+
+var formatContextType = reflect.TypeOf(FormatContext{})
+
+// Format renders a child value in one line with the rules of teq, including registered formats.
+func (c FormatContext) Format(v any) string {
+	return c.format(v)
+}
+
+// callFormat formats v with the registered format function f.
+// A panic in f propagates as *callbackPanic.
+func (teq Teq) callFormat(f reflect.Value, v reflect.Value, p *path, pathKnown bool, depth int) string {
+	args := []reflect.Value{v}
+	if f.Type().NumIn() == 2 {
+		ctx := FormatContext{
+			format: func(child any) string {
+				if child == nil {
+					return "nil"
+				}
+				return teq.formatValueAt(reflect.ValueOf(child), p, pathKnown, depth+1)
+			},
+		}
+		// Without the location, depth only bounds nested formatting and isn't the depth from the root.
+		if pathKnown {
+			ctx.Path = p.String()
+			ctx.Depth = depth
+		}
+		args = []reflect.Value{reflect.ValueOf(ctx), v}
+	}
+	out, cp := callback("format", f, p, args...)
+	if cp != nil {
+		cp.pathKnown = pathKnown
+		panic(cp)
+	}
+	return out.String()
+}
+
+// akashiFormat adapts a registered format function to func(T) string, which akashi accepts.
+// akashi doesn't tell where the value is, so the path is unknown.
+func (teq Teq) akashiFormat(f reflect.Value) any {
+	in := f.Type().In(f.Type().NumIn() - 1)
+	out := f.Type().Out(0)
+	ty := reflect.FuncOf([]reflect.Type{in}, []reflect.Type{out}, false)
+	return reflect.MakeFunc(ty, func(args []reflect.Value) []reflect.Value {
+		s := teq.callFormat(f, args[0], nil, false, 0)
+		return []reflect.Value{reflect.ValueOf(s).Convert(out)}
+	}).Interface()
+}
@@ -0,0 +1,15 @@
+package teq
+
+// EqualT is Equal with type checking.
+// Mismatched types are caught by the compiler, and untyped constants are converted to T, like EqualT(tq, t, uint8(5), 5).
+func EqualT[T any](teq Teq, t TestingT, expected, actual T, msgAndArgs ...any) bool {
+	t.Helper()
+	return teq.Equal(t, expected, actual, msgAndArgs...)
+}
+
+// NotEqualT is NotEqual with type checking.
+// Mismatched types are caught by the compiler, and untyped constants are converted to T.
+func NotEqualT[T any](teq Teq, t TestingT, expected, actual T, msgAndArgs ...any) bool {
+	t.Helper()
+	return teq.NotEqual(t, expected, actual, msgAndArgs...)
+}
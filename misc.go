@@ -2,6 +2,7 @@ package teq
 
 import (
 	"reflect"
+	"sort"
 )
 
 func field(v reflect.Value, idx int) reflect.Value {
@@ -14,3 +15,54 @@ func field(v reflect.Value, idx int) reflect.Value {
 	rf := vc.Field(idx)
 	return reflect.NewAt(rf.Type(), rf.Addr().UnsafePointer()).Elem()
 }
+
+// sortedKeys returns the keys of the map.
+// They are sorted if they are of a basic ordered kind so that the comparison and the report are deterministic.
+func sortedKeys(m reflect.Value) []reflect.Value {
+	keys := m.MapKeys()
+	if less := keyLess(m.Type().Key()); less != nil {
+		sort.Slice(keys, func(i, j int) bool { return less(keys[i], keys[j]) })
+	}
+	return keys
+}
+
+// sortedEntries returns the keys and the values of the map, sorted by keys as sortedKeys.
+func sortedEntries(m reflect.Value) (keys, values []reflect.Value) {
+	keys = make([]reflect.Value, 0, m.Len())
+	values = make([]reflect.Value, 0, m.Len())
+	for it := m.MapRange(); it.Next(); {
+		keys = append(keys, it.Key())
+		values = append(values, it.Value())
+	}
+	if less := keyLess(m.Type().Key()); less != nil {
+		sort.Sort(entries{keys, values, less})
+	}
+	return keys, values
+}
+
+type entries struct {
+	keys, values []reflect.Value
+	less         func(a, b reflect.Value) bool
+}
+
+func (e entries) Len() int           { return len(e.keys) }
+func (e entries) Less(i, j int) bool { return e.less(e.keys[i], e.keys[j]) }
+func (e entries) Swap(i, j int) {
+	e.keys[i], e.keys[j] = e.keys[j], e.keys[i]
+	e.values[i], e.values[j] = e.values[j], e.values[i]
+}
+
+// keyLess returns the order of map keys of a basic ordered kind, or nil for other kinds.
+func keyLess(ty reflect.Type) func(a, b reflect.Value) bool {
+	switch ty.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(a, b reflect.Value) bool { return a.Int() < b.Int() }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(a, b reflect.Value) bool { return a.Uint() < b.Uint() }
+	case reflect.Float32, reflect.Float64:
+		return func(a, b reflect.Value) bool { return a.Float() < b.Float() }
+	case reflect.String:
+		return func(a, b reflect.Value) bool { return a.String() < b.String() }
+	}
+	return nil
+}
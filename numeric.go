@@ -0,0 +1,76 @@
+package teq
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+)
+
+var jsonNumberType = reflect.TypeOf(json.Number(""))
+
+// jsonNumberGrammar is the number grammar of RFC 8259.
+// big.Rat.SetString alone accepts more, e.g. "0x10" and "1/2".
+var jsonNumberGrammar = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// number is an exact numeric value for NumericEquivalence.
+type number struct {
+	rat *big.Rat
+	// inf is +1 or -1 for infinities.
+	inf int
+	nan bool
+}
+
+// toNumber returns the exact value of an integer, a float or a json.Number.
+// ok is false if v is not a number.
+func toNumber(v reflect.Value) (n number, ok bool) {
+	if v.Type() == jsonNumberType {
+		if !jsonNumberGrammar.MatchString(v.String()) {
+			return number{}, false
+		}
+		r, ok := new(big.Rat).SetString(v.String())
+		return number{rat: r}, ok
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return number{rat: new(big.Rat).SetInt64(v.Int())}, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return number{rat: new(big.Rat).SetUint64(v.Uint())}, true
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		switch {
+		case math.IsNaN(f):
+			return number{nan: true}, true
+		case math.IsInf(f, 0):
+			return number{inf: int(math.Copysign(1, f))}, true
+		}
+		// SetFloat64 is exact, so float32(0.1) and float64(0.1) are different numbers.
+		return number{rat: new(big.Rat).SetFloat64(f)}, true
+	}
+	return number{}, false
+}
+
+func (n number) equal(m number) bool {
+	if n.nan || m.nan {
+		return false
+	}
+	if n.inf != 0 || m.inf != 0 {
+		return n.inf == m.inf
+	}
+	return n.rat.Cmp(m.rat) == 0
+}
+
+// numericEqual compares numbers of different types, or json.Number values, by their values under NumericEquivalence.
+// ok is false if they are not compared as numbers.
+func (teq Teq) numericEqual(v1, v2 reflect.Value) (eq, ok bool) {
+	if !teq.NumericEquivalence || (v1.Type() == v2.Type() && v1.Type() != jsonNumberType) {
+		return false, false
+	}
+	n1, ok1 := toNumber(v1)
+	n2, ok2 := toNumber(v2)
+	if !ok1 || !ok2 {
+		return false, false
+	}
+	return n1.equal(n2), true
+}
@@ -0,0 +1,70 @@
+package teq
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// OpaquePolicy decides how values of opaque kinds, which are funcs, channels and unsafe pointers, are compared.
+type OpaquePolicy int
+
+const (
+	// OpaqueDefault compares channels and unsafe pointers by identity, and considers funcs equal only if both are nil.
+	OpaqueDefault OpaquePolicy = iota
+	// OpaqueIgnore considers any values equal.
+	OpaqueIgnore
+	// OpaqueIdentity considers values equal if they are the same channel, the same pointer or the same function code.
+	// Beware that closures from the same function literal share the same code,
+	// so they are equal even if they capture different values.
+	OpaqueIdentity
+	// OpaqueFail refuses to compare non-nil values, failing the assertion with the path.
+	OpaqueFail
+)
+
+// refusal describes a comparison refused by OpaqueFail.
+type refusal struct {
+	path  *path
+	typ   reflect.Type
+	field string
+}
+
+func (r *refusal) String() string {
+	return fmt.Sprintf(
+		"refused to compare %s at %s. set Teq.%s to OpaqueIgnore or OpaqueIdentity, or register an equal function for the type.",
+		r.typ, describePath(r.path), r.field,
+	)
+}
+
+func opaque(k reflect.Kind) bool {
+	return k == reflect.Func || k == reflect.Chan || k == reflect.UnsafePointer
+}
+
+// opaqueEq compares values of opaque kinds according to the policy for the kind.
+func (teq Teq) opaqueEq(tk *task, c *comparison) bool {
+	v1, v2 := tk.v1, tk.v2
+	policy, field := teq.Funcs, "Funcs"
+	switch v1.Kind() {
+	case reflect.Chan:
+		policy, field = teq.Chans, "Chans"
+	case reflect.UnsafePointer:
+		policy, field = teq.UnsafePointers, "UnsafePointers"
+	}
+	if policy == OpaqueIgnore {
+		tk.note("ignored")
+		return true
+	}
+	if v1.IsNil() || v2.IsNil() {
+		return v1.IsNil() == v2.IsNil()
+	}
+	switch {
+	case policy == OpaqueFail:
+		tk.note("refused")
+		c.refused = &refusal{path: tk.path(), typ: v1.Type(), field: field}
+		return false
+	case policy == OpaqueIdentity || v1.Kind() != reflect.Func:
+		tk.note("compared by identity")
+		return v1.Pointer() == v2.Pointer()
+	}
+	// Funcs can't be compared better than this by default.
+	return false
+}
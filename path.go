@@ -0,0 +1,108 @@
+package teq
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// path locates a value from the root of a comparison.
+// It is a persistent linked list so that extending it is cheap even for deep structures.
+// nil represents the root.
+type path struct {
+	parent *path
+	step
+}
+
+// step leads from a value to one of its children.
+type step struct {
+	kind   stepKind
+	idx    int
+	name   string
+	mapKey reflect.Value
+	// of is the struct type of a field step whose name is looked up only when the step is put on a path.
+	of reflect.Type
+}
+
+type stepKind int
+
+const (
+	// sameStep leads to a value at the same path, like the element of a pointer.
+	sameStep stepKind = iota
+	indexStep
+	fieldStep
+	keyStep
+)
+
+func (p *path) index(i int) *path {
+	return p.then(step{kind: indexStep, idx: i})
+}
+
+func (p *path) field(name string) *path {
+	return p.then(step{kind: fieldStep, name: name})
+}
+
+func (p *path) key(k reflect.Value) *path {
+	return p.then(step{kind: keyStep, mapKey: k})
+}
+
+// then extends the path by s.
+func (p *path) then(s step) *path {
+	if s.kind == sameStep {
+		return p
+	}
+	if s.of != nil {
+		s.name, s.of = s.of.Field(s.idx).Name, nil
+	}
+	return &path{parent: p, step: s}
+}
+
+// String renders the path like `.Items[2].Tags["a"]`. The root is rendered as an empty string.
+func (p *path) String() string {
+	return strings.Join(p.steps(), "")
+}
+
+func (p *path) steps() []string {
+	var steps []string
+	for q := p; q != nil; q = q.parent {
+		steps = append(steps, q.step.String())
+	}
+	for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
+	}
+	return steps
+}
+
+func (s step) String() string {
+	switch s.kind {
+	case indexStep:
+		return fmt.Sprintf("[%d]", s.idx)
+	case fieldStep:
+		return "." + s.name
+	case keyStep:
+		if s.mapKey.CanInterface() {
+			return fmt.Sprintf("[%#v]", s.mapKey.Interface())
+		}
+		return fmt.Sprintf("[%v]", s.mapKey)
+	}
+	return ""
+}
+
+// describePath renders the path for messages.
+// Long paths, typically from linked lists, are abbreviated in the middle.
+func describePath(p *path) string {
+	if p == nil {
+		return "(root)"
+	}
+	const keep = 8
+	steps := p.steps()
+	if len(steps) <= 2*keep {
+		return strings.Join(steps, "")
+	}
+	return fmt.Sprintf(
+		"%s...(%d steps)...%s",
+		strings.Join(steps[:keep], ""),
+		len(steps)-2*keep,
+		strings.Join(steps[len(steps)-keep:], ""),
+	)
+}
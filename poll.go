@@ -0,0 +1,192 @@
+package teq
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// maxHistory is the number of distinct values shown in the history of a polling assertion.
+const maxHistory = 10
+
+// Eventually calls f every interval until its result equals expected, and reports error if it doesn't within timeout.
+// f must be a function without arguments returning one value, like func() int.
+// On failure, the report shows the last difference and how the value evolved.
+func (teq Teq) Eventually(t TestingT, expected, f any, timeout, interval time.Duration) bool {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return teq.EventuallyContext(ctx, t, expected, f, interval)
+}
+
+// EventuallyContext is like Eventually but polls until ctx is done instead of a timeout.
+func (teq Teq) EventuallyContext(ctx context.Context, t TestingT, expected, f any, interval time.Duration) bool {
+	t.Helper()
+	defer reportInternalPanic(t)
+	fn, ok := pollFunc(t, f)
+	if !ok {
+		return false
+	}
+	ctx, cancel := cancelOnCleanup(ctx, t)
+	defer cancel()
+
+	var h history
+	for {
+		v, cp := fn()
+		if cp != nil {
+			t.Error(cp.String())
+			return false
+		}
+		h.add(teq.describe(v))
+		eq, ok := teq.equal(t, expected, v)
+		if !ok {
+			return false
+		}
+		if eq {
+			return true
+		}
+		if !wait(ctx, interval) {
+			t.Error(teq.pollReport(
+				fmt.Sprintf("not equal after %d attempt(s): %v", h.attempts, ctx.Err()),
+				expected, v, h, teq.colorEnabled(),
+			))
+			return false
+		}
+	}
+}
+
+// Consistently calls f every interval for duration, and reports error as soon as its result doesn't equal expected.
+// f must be a function without arguments returning one value, like func() int.
+// On failure, the report shows the difference and how the value evolved.
+func (teq Teq) Consistently(t TestingT, expected, f any, duration, interval time.Duration) bool {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+	return teq.ConsistentlyContext(ctx, t, expected, f, interval)
+}
+
+// ConsistentlyContext is like Consistently but polls until ctx is done instead of for a duration.
+func (teq Teq) ConsistentlyContext(ctx context.Context, t TestingT, expected, f any, interval time.Duration) bool {
+	t.Helper()
+	defer reportInternalPanic(t)
+	fn, ok := pollFunc(t, f)
+	if !ok {
+		return false
+	}
+	ctx, cancel := cancelOnCleanup(ctx, t)
+	defer cancel()
+
+	var h history
+	for {
+		v, cp := fn()
+		if cp != nil {
+			t.Error(cp.String())
+			return false
+		}
+		h.add(teq.describe(v))
+		eq, ok := teq.equal(t, expected, v)
+		if !ok {
+			return false
+		}
+		if !eq {
+			t.Error(teq.pollReport(
+				fmt.Sprintf("not equal at attempt %d", h.attempts),
+				expected, v, h, teq.colorEnabled(),
+			))
+			return false
+		}
+		if !wait(ctx, interval) {
+			return true
+		}
+	}
+}
+
+func (teq Teq) pollReport(head string, expected, last any, h history, color bool) string {
+	lines := []string{head, teq.report(expected, last, color), "history:"}
+	lines = append(lines, h.lines()...)
+	return strings.Join(lines, "\n")
+}
+
+// pollFunc validates f and returns it as a plain function.
+// A panic in f is returned as *callbackPanic, since it is raised by the user's code.
+func pollFunc(t TestingT, f any) (func() (any, *callbackPanic), bool) {
+	t.Helper()
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func || v.IsNil() || v.Type().NumIn() != 0 || v.Type().NumOut() != 1 {
+		t.Errorf("f must be a function without arguments returning one value, got %T", f)
+		return nil, false
+	}
+	return func() (any, *callbackPanic) {
+		out, cp := callback("poll", v, nil)
+		if cp != nil {
+			cp.pathKnown = false
+			return nil, cp
+		}
+		return out.Interface(), nil
+	}, true
+}
+
+type cleanuper interface {
+	Cleanup(func())
+}
+
+// cancelOnCleanup derives a context canceled when the test finishes,
+// so that polling from a goroutine doesn't outlive the test.
+func cancelOnCleanup(ctx context.Context, t TestingT) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	if c, ok := t.(cleanuper); ok {
+		c.Cleanup(cancel)
+	}
+	return ctx, cancel
+}
+
+// wait sleeps for interval. It returns false without waiting for the rest if ctx is done.
+func wait(ctx context.Context, interval time.Duration) bool {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return ctx.Err() == nil
+	}
+}
+
+// history records distinct consecutive values observed by polling.
+type history struct {
+	attempts int
+	entries  []historyEntry
+}
+
+type historyEntry struct {
+	first, last int
+	value       string
+}
+
+func (h *history) add(value string) {
+	h.attempts++
+	if n := len(h.entries); n > 0 && h.entries[n-1].value == value {
+		h.entries[n-1].last = h.attempts
+		return
+	}
+	h.entries = append(h.entries, historyEntry{h.attempts, h.attempts, value})
+}
+
+func (h history) lines() []string {
+	var lines []string
+	entries := h.entries
+	if len(entries) > maxHistory {
+		lines = append(lines, fmt.Sprintf("… %d earlier value(s) omitted", len(entries)-maxHistory))
+		entries = entries[len(entries)-maxHistory:]
+	}
+	for _, e := range entries {
+		if e.first == e.last {
+			lines = append(lines, fmt.Sprintf("#%d: %s", e.first, e.value))
+		} else {
+			lines = append(lines, fmt.Sprintf("#%d-#%d: %s", e.first, e.last, e.value))
+		}
+	}
+	return lines
+}
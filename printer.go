@@ -0,0 +1,129 @@
+package teq
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// maxPrintDepth bounds the nesting printed by formatValue.
+const maxPrintDepth = 100
+
+// formatValue renders v in one line, in the same notation as the diff report.
+// Registered formats are applied.
+func (teq Teq) formatValue(v reflect.Value) string {
+	return teq.formatValueAt(v, nil, true, 0)
+}
+
+// formatValueAt is formatValue for a value at the path and the depth, which are told to format functions.
+func (teq Teq) formatValueAt(v reflect.Value, p *path, pathKnown bool, depth int) string {
+	pr := printer{teq: teq, visiting: make(map[uintptr]*path), pathKnown: pathKnown}
+	var b strings.Builder
+	pr.print(&b, v, p, depth)
+	return b.String()
+}
+
+type printer struct {
+	teq Teq
+	// visiting holds pointers being printed and their paths to detect cycles.
+	visiting  map[uintptr]*path
+	pathKnown bool
+}
+
+func (pr printer) print(b *strings.Builder, v reflect.Value, p *path, depth int) {
+	if !v.IsValid() {
+		b.WriteString("<absent>")
+		return
+	}
+	if depth > maxPrintDepth {
+		b.WriteString("...")
+		return
+	}
+	if f, ok := pr.teq.formatFor(v); ok {
+		fmt.Fprintf(b, "%s(%q)", v.Type(), pr.teq.callFormat(f, v, p, pr.pathKnown, depth))
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		b.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		b.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		b.WriteString(strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()))
+	case reflect.Complex64, reflect.Complex128:
+		b.WriteString(strconv.FormatComplex(v.Complex(), 'g', -1, v.Type().Bits()))
+	case reflect.String:
+		b.WriteString(strconv.Quote(v.String()))
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		if v.IsNil() {
+			fmt.Fprintf(b, "%s(nil)", v.Type())
+			return
+		}
+		fmt.Fprintf(b, "%s at [%#x]", v.Type(), v.Pointer())
+	case reflect.Interface:
+		if v.IsNil() {
+			fmt.Fprintf(b, "%s(nil)", v.Type())
+			return
+		}
+		pr.print(b, v.Elem(), p, depth+1)
+	case reflect.Pointer:
+		if v.IsNil() {
+			fmt.Fprintf(b, "%s(nil)", v.Type())
+			return
+		}
+		if target, ok := pr.visiting[v.Pointer()]; ok {
+			fmt.Fprintf(b, "&<cycle to %s>", describePath(target))
+			return
+		}
+		pr.visiting[v.Pointer()] = p
+		defer delete(pr.visiting, v.Pointer())
+		b.WriteString("&")
+		pr.print(b, v.Elem(), p, depth+1)
+	case reflect.Struct:
+		fmt.Fprintf(b, "%s{", v.Type())
+		for i := 0; i < v.NumField(); i++ {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(b, "%s: ", v.Type().Field(i).Name)
+			pr.print(b, field(v, i), p.field(v.Type().Field(i).Name), depth+1)
+		}
+		b.WriteString("}")
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			fmt.Fprintf(b, "%s(nil)", v.Type())
+			return
+		}
+		fmt.Fprintf(b, "%s{", v.Type())
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			pr.print(b, v.Index(i), p.index(i), depth+1)
+		}
+		b.WriteString("}")
+	case reflect.Map:
+		if v.IsNil() {
+			fmt.Fprintf(b, "%s(nil)", v.Type())
+			return
+		}
+		fmt.Fprintf(b, "%s{", v.Type())
+		// Values are taken from the entries, since MapIndex can't find NaN keys.
+		keys, values := sortedEntries(v)
+		for i, k := range keys {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			pr.print(b, k, p, depth+1)
+			b.WriteString(": ")
+			pr.print(b, values[i], p.key(k), depth+1)
+		}
+		b.WriteString("}")
+	default:
+		fmt.Fprintf(b, "%v", v)
+	}
+}
@@ -0,0 +1,208 @@
+package teq
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// Reporter renders the failure message of Equal.
+type Reporter interface {
+	Report(r Report) string
+}
+
+// Report is the material for a Reporter.
+type Report struct {
+	Expected any
+	Actual   any
+
+	teq   Teq
+	color bool
+}
+
+// Difference is a pair of values that differ.
+type Difference struct {
+	// Path locates the values from the root, such as `.Items[2].Name`. It is empty at the root.
+	Path string
+	// Expected and Actual are the values rendered in one line with registered formats.
+	// A value absent on its side, such as a missing map key, is rendered as "<absent>".
+	Expected string
+	Actual   string
+}
+
+// Differences compares Expected and Actual thoroughly and returns every pair of values that differ.
+// A pair of containers that can't be compared element-wise, such as slices with different lengths, is a single difference.
+func (r Report) Differences() []Difference {
+	teq := r.teq
+	if r.Expected == nil || r.Actual == nil {
+		return []Difference{{
+			Expected: r.Format(r.Expected),
+			Actual:   r.Format(r.Actual),
+		}}
+	}
+	v1 := reflect.ValueOf(r.Expected)
+	v2 := reflect.ValueOf(r.Actual)
+	c := newComparison()
+	c.exhaustive = true
+	c.collect = true
+	teq.deepValueEqual(v1, v2, c)
+	if c.panicked != nil {
+		panic(c.panicked)
+	}
+	ds := make([]Difference, 0, len(c.differences))
+	for _, d := range c.differences {
+		ds = append(ds, Difference{
+			Path:     d.path.String(),
+			Expected: teq.formatValueAt(d.v1, d.path, true, d.depth),
+			Actual:   teq.formatValueAt(d.v2, d.path, true, d.depth),
+		})
+	}
+	return ds
+}
+
+// Diff returns the unified diff of Expected and Actual rendered by akashi with registered formats.
+func (r Report) Diff() string {
+	return r.teq.diff(r.Expected, r.Actual)
+}
+
+// Color tells whether the report should be colored with ANSI escape sequences according to Teq.Color.
+func (r Report) Color() bool {
+	return r.color
+}
+
+// Labels returns the names of the expected and the actual values, which are Teq.ExpectedLabel and Teq.ActualLabel or their defaults.
+func (r Report) Labels() (expected, actual string) {
+	return r.teq.labels()
+}
+
+// Format renders v in one line with registered formats.
+func (r Report) Format(v any) string {
+	if v == nil {
+		return "nil"
+	}
+	return r.teq.formatValue(reflect.ValueOf(v))
+}
+
+// UnifiedReporter renders a unified diff by akashi. It is the default Reporter.
+// Simple values are reported in one line.
+type UnifiedReporter struct{}
+
+func (UnifiedReporter) Report(r Report) string {
+	return r.teq.unifiedReport(r.Expected, r.Actual, r.color)
+}
+
+// SideBySideReporter renders the diff in two columns, expected on the left and actual on the right.
+type SideBySideReporter struct{}
+
+func (SideBySideReporter) Report(r Report) string {
+	type row struct{ left, right string }
+	var rows []row
+	var removed, added []string
+	flush := func() {
+		for i := 0; i < len(removed) || i < len(added); i++ {
+			var rw row
+			if i < len(removed) {
+				rw.left = removed[i]
+			}
+			if i < len(added) {
+				rw.right = added[i]
+			}
+			rows = append(rows, rw)
+		}
+		removed, added = nil, nil
+	}
+	for _, line := range r.teq.limitDiffLines(strings.Split(r.Diff(), "\n")) {
+		switch {
+		case strings.HasPrefix(line, "-"):
+			if len(added) > 0 {
+				flush()
+			}
+			removed = append(removed, line)
+		case strings.HasPrefix(line, "+"):
+			added = append(added, line)
+		default:
+			flush()
+			rows = append(rows, row{line, line})
+		}
+	}
+	flush()
+
+	el, al := r.teq.labels()
+	width := utf8.RuneCountInString(el)
+	for _, rw := range rows {
+		if w := utf8.RuneCountInString(rw.left); w > width {
+			width = w
+		}
+	}
+	lines := []string{"not equal", "differences:"}
+	rows = append([]row{{el, al}}, rows...)
+	for _, rw := range rows {
+		pad := strings.Repeat(" ", width-utf8.RuneCountInString(rw.left))
+		left, right := rw.left+pad, rw.right
+		if r.color {
+			left, right = colorizeCell(left), colorizeCell(right)
+		}
+		lines = append(lines, strings.TrimRight(left+" | "+right, " "))
+	}
+	return r.teq.limitSize(strings.Join(lines, "\n"))
+}
+
+func colorizeCell(cell string) string {
+	switch {
+	case strings.HasPrefix(cell, "-"):
+		return ansiRed + cell + ansiReset
+	case strings.HasPrefix(cell, "+"):
+		return ansiGreen + cell + ansiReset
+	}
+	return cell
+}
+
+// PathReporter renders one line per difference with its path.
+type PathReporter struct{}
+
+func (PathReporter) Report(r Report) string {
+	all := r.Differences()
+	el, al := r.teq.labels()
+	lines := []string{fmt.Sprintf("not equal: %d difference(s)", len(all))}
+	ds, omitted := r.teq.limitDifferences(all)
+	for _, d := range ds {
+		p := d.Path
+		if p == "" {
+			p = "(root)"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s %s, %s %s", p, el, d.Expected, al, d.Actual))
+	}
+	if omitted > 0 {
+		lines = append(lines, omittedDifferences(omitted))
+	}
+	return r.teq.limitSize(strings.Join(lines, "\n"))
+}
+
+// JSONReporter renders differences as a JSON object like {"differences":[{"path":".a","expected":"1","actual":"2"}]}.
+// If some differences are cut by MaxDifferences, the number of them is put in "omitted".
+type JSONReporter struct{}
+
+func (JSONReporter) Report(r Report) string {
+	type difference struct {
+		Path     string `json:"path"`
+		Expected string `json:"expected"`
+		Actual   string `json:"actual"`
+	}
+	var out struct {
+		Differences []difference `json:"differences"`
+		Omitted     int          `json:"omitted,omitempty"`
+	}
+	ds, omitted := r.teq.limitDifferences(r.Differences())
+	out.Differences = []difference{}
+	out.Omitted = omitted
+	for _, d := range ds {
+		out.Differences = append(out.Differences, difference(d))
+	}
+	b, err := json.Marshal(out)
+	if err != nil {
+		return fmt.Sprintf("failed to render report as JSON: %v", err)
+	}
+	return string(b)
+}
@@ -0,0 +1,223 @@
+package teq
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxLineDiffCells bounds the size of the table for the line diff.
+// Beyond it, the lines between the common prefix and suffix are reported as replaced entirely.
+const maxLineDiffCells = 4_000_000
+
+type lineOp int
+
+const (
+	lineEqual lineOp = iota
+	lineRemoved
+	lineAdded
+)
+
+type diffLine struct {
+	op   lineOp
+	text string
+}
+
+// lineDiff returns an edit script from a to b based on the longest common subsequence.
+func lineDiff(a, b []string) []diffLine {
+	var prefix, suffix []diffLine
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		prefix = append(prefix, diffLine{lineEqual, a[0]})
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		suffix = append([]diffLine{{lineEqual, a[len(a)-1]}}, suffix...)
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	var middle []diffLine
+	if (len(a)+1)*(len(b)+1) > maxLineDiffCells {
+		for _, l := range a {
+			middle = append(middle, diffLine{lineRemoved, l})
+		}
+		for _, l := range b {
+			middle = append(middle, diffLine{lineAdded, l})
+		}
+	} else {
+		// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+		lcs := make([][]int, len(a)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else if lcs[i+1][j] >= lcs[i][j+1] {
+					lcs[i][j] = lcs[i+1][j]
+				} else {
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(a) || j < len(b) {
+			switch {
+			case i < len(a) && j < len(b) && a[i] == b[j]:
+				middle = append(middle, diffLine{lineEqual, a[i]})
+				i++
+				j++
+			case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+				middle = append(middle, diffLine{lineRemoved, a[i]})
+				i++
+			default:
+				middle = append(middle, diffLine{lineAdded, b[j]})
+				j++
+			}
+		}
+	}
+	return append(append(prefix, middle...), suffix...)
+}
+
+// stringDiff renders the line diff of multi-line strings.
+// Unchanged lines farther than ContextLines from any change are collapsed into ":".
+func (teq Teq) stringDiff(ty reflect.Type, a, b string) []string {
+	context := teq.ContextLines
+	ls := lineDiff(strings.Split(a, "\n"), strings.Split(b, "\n"))
+
+	// near[i] tells whether ls[i] is within context lines from a change.
+	near := make([]bool, len(ls))
+	last := -1
+	for i, l := range ls {
+		if l.op != lineEqual {
+			last = i
+		}
+		if last >= 0 && i-last <= context {
+			near[i] = true
+		}
+	}
+	last = -1
+	for i := len(ls) - 1; i >= 0; i-- {
+		if ls[i].op != lineEqual {
+			last = i
+		}
+		if last >= 0 && last-i <= context {
+			near[i] = true
+		}
+	}
+
+	lines := []string{fmt.Sprintf("  %s(", ty)}
+	collapsed := false
+	for i, l := range ls {
+		if !near[i] {
+			if !collapsed {
+				lines = append(lines, ":")
+				collapsed = true
+			}
+			continue
+		}
+		collapsed = false
+		text := teq.escapeLine(l.text)
+		switch l.op {
+		case lineEqual:
+			lines = append(lines, "    "+text)
+		case lineRemoved:
+			lines = append(lines, "-   "+text)
+		case lineAdded:
+			lines = append(lines, "+   "+text)
+		}
+	}
+	return append(lines, "  )")
+}
+
+// escapeLine makes characters in a line of a string diff visible.
+// Control characters other than tab and invalid UTF-8 bytes are always escaped like `\x00`.
+// With ShowWhitespace, spaces, tabs and carriage returns are rendered as "·", "→" and "␍".
+func (teq Teq) escapeLine(line string) string {
+	var b strings.Builder
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&b, `\x%02x`, line[i])
+		case teq.ShowWhitespace && r == ' ':
+			b.WriteString("·")
+		case teq.ShowWhitespace && r == '\t':
+			b.WriteString("→")
+		case teq.ShowWhitespace && r == '\r':
+			b.WriteString("␍")
+		case r == '\t':
+			b.WriteRune(r)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case unicode.IsControl(r):
+			q := strconv.QuoteRune(r)
+			b.WriteString(q[1 : len(q)-1])
+		default:
+			b.WriteRune(r)
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// markChanges puts a line of carets under each added line, pointing at characters changed from the paired removed line.
+// It is for plain text, where changes can't be highlighted with colors.
+func markChanges(lines []string) []string {
+	out := make([]string, 0, len(lines))
+	var removed, added []string
+	flush := func() {
+		out = append(out, removed...)
+		for i, a := range added {
+			out = append(out, a)
+			if i >= len(removed) {
+				continue
+			}
+			if carets := caretLine([]rune(removed[i]), []rune(a)); carets != "" {
+				out = append(out, carets)
+			}
+		}
+		removed, added = nil, nil
+	}
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "-"):
+			if len(added) > 0 {
+				flush()
+			}
+			removed = append(removed, line)
+		case strings.HasPrefix(line, "+"):
+			added = append(added, line)
+		default:
+			flush()
+			out = append(out, line)
+		}
+	}
+	flush()
+	return out
+}
+
+// caretLine points at changed characters of b from a.
+// Lines of the same length, such as rows of hexdump, are compared character by character.
+// Otherwise, the span between the common prefix and suffix is pointed at.
+func caretLine(a, b []rune) string {
+	if len(a) == len(b) {
+		carets := make([]rune, len(b))
+		for i := 1; i < len(b); i++ {
+			carets[i] = ' '
+			if a[i] != b[i] {
+				carets[i] = '^'
+			}
+		}
+		carets[0] = ' '
+		return strings.TrimRight(string(carets), " ")
+	}
+	prefix, suffix, ok := changedSpan(a, b)
+	if !ok || len(b)-suffix == prefix {
+		return ""
+	}
+	return strings.Repeat(" ", prefix) + strings.Repeat("^", len(b)-suffix-prefix)
+}
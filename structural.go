@@ -0,0 +1,161 @@
+package teq
+
+import (
+	"reflect"
+)
+
+// OneSidedPolicy decides how a field present on only one side is treated under Structural.
+type OneSidedPolicy int
+
+const (
+	// OneSidedFail reports a field present on only one side as a difference.
+	OneSidedFail OneSidedPolicy = iota
+	// OneSidedIgnore ignores fields present on only one side.
+	OneSidedIgnore
+	// OneSidedZero ignores fields present on only one side if they are zero values.
+	OneSidedZero
+)
+
+// structuralEq compares values of different types under Structural.
+// ok is false if they can't be compared structurally.
+func (teq Teq) structuralEq(v1, v2 reflect.Value, c *comparison, nx next) (eq, ok bool) {
+	k1, k2 := v1.Kind(), v2.Kind()
+	switch {
+	case k1 == reflect.Interface || k2 == reflect.Interface:
+		n1 := k1 == reflect.Interface && v1.IsNil() || nillable(k1) && v1.IsNil()
+		n2 := k2 == reflect.Interface && v2.IsNil() || nillable(k2) && v2.IsNil()
+		if n1 || n2 {
+			return n1 == n2, true
+		}
+		if k1 == reflect.Interface {
+			v1 = v1.Elem()
+		}
+		if k2 == reflect.Interface {
+			v2 = v2.Elem()
+		}
+		nx(same(v1, v2))
+		return true, true
+	case k1 == reflect.Pointer && k2 == reflect.Pointer:
+		if v1.IsNil() || v2.IsNil() {
+			return v1.IsNil() == v2.IsNil(), true
+		}
+		vis := visit{v1.UnsafePointer(), v2.UnsafePointer(), v1.Type()}
+		if c.visited[vis] {
+			return true, true
+		}
+		c.visited[vis] = true
+		nx(same(v1.Elem(), v2.Elem()))
+		return true, true
+	case (k1 == reflect.Slice || k1 == reflect.Array) && (k2 == reflect.Slice || k2 == reflect.Array):
+		if k1 == reflect.Slice && k2 == reflect.Slice && v1.IsNil() != v2.IsNil() {
+			return false, true
+		}
+		if v1.Len() != v2.Len() {
+			return false, true
+		}
+		nx(elements(v1, v2))
+		return true, true
+	case k1 == reflect.Map && k2 == reflect.Map && v1.Type().Key() == v2.Type().Key():
+		if v1.IsNil() != v2.IsNil() {
+			return false, true
+		}
+		ch, ok := mapChildren(v1, v2, c)
+		if !ok {
+			return false, true
+		}
+		nx(ch)
+		return true, true
+	}
+
+	names1, f1, ok1 := structuralFields(v1)
+	names2, f2, ok2 := structuralFields(v2)
+	if !ok1 || !ok2 || k1 != reflect.Struct && k2 != reflect.Struct {
+		return false, false
+	}
+	names := names1
+	for _, name := range names2 {
+		if _, ok := f1[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	var cs []child
+	for _, name := range names {
+		a, b := f1[name], f2[name]
+		if !a.IsValid() || !b.IsValid() {
+			one := a
+			if !one.IsValid() {
+				one = b
+			}
+			if teq.OneSidedFields == OneSidedIgnore || teq.OneSidedFields == OneSidedZero && one.IsZero() {
+				continue
+			}
+		}
+		cs = append(cs, child{a, b, step{kind: fieldStep, name: name}})
+	}
+	nx(listed(cs))
+	return true, true
+}
+
+// structuralFields returns the fields of a struct, or the entries of a map with string keys, by name.
+// names are in the order of declaration for a struct, and sorted for a map.
+// Embedded structs are flattened into their promoted fields, as encoding/json does.
+// Fields promoted through a nil pointer are absent.
+func structuralFields(v reflect.Value) (names []string, fields map[string]reflect.Value, ok bool) {
+	switch v.Kind() {
+	case reflect.Struct:
+		fields = make(map[string]reflect.Value, v.NumField())
+		for _, f := range reflect.VisibleFields(v.Type()) {
+			if f.Anonymous && embeddedStruct(f.Type) {
+				continue
+			}
+			fv, ok := fieldByIndex(v, f.Index)
+			if !ok {
+				continue
+			}
+			names = append(names, f.Name)
+			fields[f.Name] = fv
+		}
+		return names, fields, true
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, nil, false
+		}
+		fields = make(map[string]reflect.Value, v.Len())
+		for _, k := range sortedKeys(v) {
+			names = append(names, k.String())
+			fields[k.String()] = v.MapIndex(k)
+		}
+		return names, fields, true
+	}
+	return nil, nil, false
+}
+
+func embeddedStruct(ty reflect.Type) bool {
+	if ty.Kind() == reflect.Pointer {
+		ty = ty.Elem()
+	}
+	return ty.Kind() == reflect.Struct
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but it can read unexported fields and reports a nil embedded pointer with ok.
+func fieldByIndex(v reflect.Value, index []int) (f reflect.Value, ok bool) {
+	f = v
+	for i, idx := range index {
+		if i > 0 && f.Kind() == reflect.Pointer {
+			if f.IsNil() {
+				return reflect.Value{}, false
+			}
+			f = f.Elem()
+		}
+		f = field(f, idx)
+	}
+	return f, true
+}
+
+func nillable(k reflect.Kind) bool {
+	switch k {
+	case reflect.Chan, reflect.Func, reflect.Map, reflect.Pointer, reflect.Slice, reflect.UnsafePointer:
+		return true
+	}
+	return false
+}
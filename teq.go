@@ -1,59 +1,162 @@
 package teq
 
 import (
+	"fmt"
 	"reflect"
 )
 
 // Teq is a object for deep equality comparison.
 type Teq struct {
 	// MaxDepth is the maximum depth of the comparison. Default is 1000.
+	// The comparison doesn't recurse, so MaxDepth is a policy rather than a protection of the stack.
+	// Zero or negative value means no limit.
 	MaxDepth int
+	// OnMaxDepth decides how a comparison exceeding MaxDepth is treated. Default is MaxDepthFail.
+	OnMaxDepth MaxDepthPolicy
+	// Verbose makes Equal and NotEqual log the result of Explain.
+	Verbose bool
+	// Reporter renders the failure message of Equal. Default is UnifiedReporter.
+	Reporter Reporter
+	// Color decides whether failure messages are colored. Default is ColorAuto.
+	Color ColorMode
+	// ContextLines is the number of unchanged lines shown around a change in diffs of multi-line strings. Default is 2.
+	ContextLines int
+	// ShowWhitespace renders spaces, tabs and carriage returns in diffs of multi-line strings as "·", "→" and "␍".
+	ShowWhitespace bool
+	// BytesAsText makes byte slices and byte arrays diffed as text instead of hexdump when both are valid UTF-8.
+	BytesAsText bool
+	// MaxDifferences limits the number of differences shown in a report. Zero means no limit.
+	MaxDifferences int
+	// MaxReportSize limits the size of a report in bytes. Zero means no limit.
+	// JSONReporter ignores it to keep its output valid JSON.
+	MaxReportSize int
+	// AutoFormat renders values implementing error, fmt.Stringer or encoding.TextMarshaler in reports
+	// with Error, String or MarshalText, in this order of precedence.
+	// Formats registered with AddFormat take precedence over them. Use DisableAutoFormat to opt out for a type.
+	AutoFormat bool
+	// ErrorEquality decides how values implementing error are compared. Default is ErrorStructural.
+	// Registered equal and transform functions for an error type take precedence over it.
+	ErrorEquality ErrorMode
+	// NumericEquivalence makes numbers of different types, including json.Number, compared by their exact values.
+	// For example, int64(1), float64(1) and json.Number("1.0") are equal. NaN is never equal to anything.
+	// Reports note where a difference of types was tolerated.
+	NumericEquivalence bool
+	// Structural makes values of different types compared by their structure.
+	// Structs of different types are compared field by field by name, and a struct can be compared with a map with string keys,
+	// like map[string]any, whose keys are regarded as field names. Slices, arrays, pointers and maps of different types are compared element by element.
+	// Fields of embedded structs are promoted like encoding/json does, so a struct embedding a base is compared with a flat one by the base's fields.
+	Structural bool
+	// OneSidedFields decides how a field present on only one side is treated under Structural. Default is OneSidedFail.
+	OneSidedFields OneSidedPolicy
+	// StrictCycles requires references on both sides to correspond one-to-one,
+	// so that cyclic structures are equal only if they have the same shape.
+	// Shared references must match too, even without cycles: []*T{x, x} is not equal to []*T{y, z}.
+	// By default, a pair of references met again is assumed equal, which can't tell a self loop from a longer cycle of equal nodes.
+	StrictCycles bool
+	// Funcs, Chans and UnsafePointers decide how funcs, channels and unsafe pointers are compared.
+	// Default is OpaqueDefault, which compares channels and unsafe pointers by identity
+	// and considers funcs equal only if both are nil.
+	Funcs          OpaquePolicy
+	Chans          OpaquePolicy
+	UnsafePointers OpaquePolicy
+	// ExpectedLabel and ActualLabel name the values in failure messages, such as "want" and "got" or "before" and "after".
+	// Defaults are "expected" and "actual".
+	ExpectedLabel string
+	ActualLabel   string
 
-	transforms map[reflect.Type]func(reflect.Value) reflect.Value
-	formats    map[reflect.Type]any
-	equals     map[reflect.Type]func(reflect.Value, reflect.Value) bool
+	transforms   map[reflect.Type]reflect.Value
+	formats      map[reflect.Type]reflect.Value
+	equals       map[reflect.Type]reflect.Value
+	noAutoFormat map[reflect.Type]bool
 }
 
+// MaxDepthPolicy decides how a comparison exceeding MaxDepth is treated.
+type MaxDepthPolicy int
+
+const (
+	// MaxDepthFail reports exceeding MaxDepth as a test failure.
+	MaxDepthFail MaxDepthPolicy = iota
+	// MaxDepthUnknown treats values beyond MaxDepth as unknown.
+	// Equal and NotEqual don't fail unless a definite difference is found elsewhere, and log a note instead.
+	MaxDepthUnknown
+)
+
 // New returns new instance of Teq.
 func New() Teq {
 	return Teq{
-		MaxDepth: 1_000,
+		MaxDepth:     1_000,
+		ContextLines: 2,
 
-		transforms: make(map[reflect.Type]func(reflect.Value) reflect.Value),
-		formats:    make(map[reflect.Type]any),
-		equals:     make(map[reflect.Type]func(reflect.Value, reflect.Value) bool),
+		transforms:   make(map[reflect.Type]reflect.Value),
+		formats:      make(map[reflect.Type]reflect.Value),
+		equals:       make(map[reflect.Type]reflect.Value),
+		noAutoFormat: make(map[reflect.Type]bool),
 	}
 }
 
 // Equal perform deep equality check and report error if not equal.
-func (teq Teq) Equal(t TestingT, expected, actual any) bool {
+// msgAndArgs optionally adds context to the failure message, like "case %s", name.
+func (teq Teq) Equal(t TestingT, expected, actual any, msgAndArgs ...any) bool {
 	t.Helper()
-	defer func() {
-		if r := recover(); r != nil {
-			t.Errorf("panic in github.com/seiyab/teq. please report issue. message: %v", r)
-		}
-	}()
-	ok := teq.equal(expected, actual)
-	if !ok {
-		t.Error(teq.report(expected, actual))
+	defer reportInternalPanic(t)
+	if teq.Verbose {
+		t.Log(teq.Explain(expected, actual))
 	}
-	return ok
+	res := teq.compare(expected, actual)
+	if res.panicked != nil {
+		t.Error(withMessage(res.panicked.String(), msgAndArgs))
+		return false
+	}
+	if res.refused != nil {
+		t.Error(withMessage(res.refused.String(), msgAndArgs))
+		return false
+	}
+	if res.exceeded != nil && teq.OnMaxDepth == MaxDepthFail {
+		t.Error(withMessage(teq.maxDepthMessage(res.exceeded), msgAndArgs))
+		return false
+	}
+	if !res.equal {
+		t.Error(withMessage(teq.report(expected, actual, teq.colorEnabled()), msgAndArgs))
+		return false
+	}
+	if res.exceeded != nil {
+		t.Log(withMessage(teq.maxDepthMessage(res.exceeded), msgAndArgs))
+		return false
+	}
+	return true
 }
 
 // NotEqual perform deep equality check and report error if equal.
-func (teq Teq) NotEqual(t TestingT, expected, actual any) bool {
+// msgAndArgs optionally adds context to the failure message, like "case %s", name.
+func (teq Teq) NotEqual(t TestingT, expected, actual any, msgAndArgs ...any) bool {
 	t.Helper()
-	defer func() {
-		if r := recover(); r != nil {
-			t.Errorf("panic in github.com/seiyab/teq. please report issue. message: %v", r)
+	defer reportInternalPanic(t)
+	if teq.Verbose {
+		t.Log(teq.Explain(expected, actual))
+	}
+	res := teq.compare(expected, actual)
+	if res.panicked != nil {
+		t.Error(withMessage(res.panicked.String(), msgAndArgs))
+		return false
+	}
+	if res.refused != nil {
+		t.Error(withMessage(res.refused.String(), msgAndArgs))
+		return false
+	}
+	if res.exceeded != nil && (teq.OnMaxDepth == MaxDepthFail || res.equal) {
+		if teq.OnMaxDepth == MaxDepthFail {
+			t.Error(withMessage(teq.maxDepthMessage(res.exceeded), msgAndArgs))
+		} else {
+			t.Log(withMessage(teq.maxDepthMessage(res.exceeded), msgAndArgs))
 		}
-	}()
-	ok := !teq.equal(expected, actual)
+		return false
+	}
+	ok := !res.equal
 	if !ok {
 		if reflect.DeepEqual(expected, actual) {
-			t.Error("reflect.DeepEqual(expected, actual) == true.")
+			t.Error(withMessage("reflect.DeepEqual(expected, actual) == true.", msgAndArgs))
 		} else {
-			t.Errorf("expected %v != %v", expected, actual)
+			t.Error(withMessage(teq.notEqualReport(expected, actual), msgAndArgs))
 			t.Log("reflect.DeepEqual(expected, actual) == false. maybe transforms made them equal.")
 		}
 	}
@@ -67,90 +170,241 @@ func (teq Teq) NotEqual(t TestingT, expected, actual any) bool {
 // If the passed transform function is not valid, it will panic.
 // The transformed value will be used for equality check instead of the original value.
 // The transformed value and its internal values won't be transformed to prevent infinite recursion.
+// A transform function registered for the same type before is replaced. Use TryAddTransform to detect it.
 func (teq *Teq) AddTransform(transform any) {
+	ty, err := transformTarget(transform)
+	if err != nil {
+		panic(err)
+	}
+	teq.transforms[ty] = reflect.ValueOf(transform)
+}
+
+// TryAddTransform is like AddTransform but returns an error instead of panicking.
+// The error is *SignatureError for an invalid function, or *ConflictError if another transform function
+// or an equal function is registered for the same type.
+func (teq *Teq) TryAddTransform(transform any) error {
+	ty, err := transformTarget(transform)
+	if err != nil {
+		return err
+	}
+	fn := reflect.ValueOf(transform)
+	if err := conflict(ty, "transform", fn, "transform", teq.transforms); err != nil {
+		return err
+	}
+	if err := conflict(ty, "transform", fn, "equal", teq.equals); err != nil {
+		return err
+	}
+	teq.transforms[ty] = fn
+	return nil
+}
+
+// transformTarget validates the signature of a transform function and returns the type to be transformed.
+func transformTarget(transform any) (reflect.Type, error) {
 	ty := reflect.TypeOf(transform)
-	if ty.Kind() != reflect.Func {
-		panic("transform must be a function")
+	if ty == nil || ty.Kind() != reflect.Func {
+		return nil, signatureError("transform", ty, "transform must be a function")
 	}
 	if ty.NumIn() != 1 {
-		panic("transform must have only one argument")
+		return nil, signatureError("transform", ty, "transform must have only one argument")
 	}
 	if ty.NumOut() != 1 {
-		panic("transform must have only one return value")
-	}
-	trValue := reflect.ValueOf(transform)
-	reflectTransform := func(v reflect.Value) reflect.Value {
-		return trValue.Call([]reflect.Value{v})[0]
+		return nil, signatureError("transform", ty, "transform must have only one return value")
 	}
-	teq.transforms[ty.In(0)] = reflectTransform
+	return ty.In(0), nil
 }
 
 // AddFormat adds a format function to Teq.
-// The format function must have only one argument and one return value.
+// The format function must have only one argument and one return value of string.
 // The argument type is the type to be formatted.
+// The format function can also take FormatContext as the first argument, like func(teq.FormatContext, T) string.
+// It is useful to format a container type, whose elements should be formatted with the other registered formats.
+// FormatContext.Path and FormatContext.Depth are supported only by PathReporter and JSONReporter.
+// They are unsupported with the default UnifiedReporter and with SideBySideReporter, since akashi, which renders their diffs,
+// doesn't tell where values are. There, they are always empty and 0, even for child values formatted with FormatContext.Format.
 // If the passed format function is not valid, it will panic.
 // The formatted string will be shown instead of the original value in the error report when the values are not equal.
+// A format function registered for the same type before is replaced. Use TryAddFormat to detect it.
 func (teq *Teq) AddFormat(format any) {
+	ty, err := formatTarget(format)
+	if err != nil {
+		panic(err)
+	}
+	teq.formats[ty] = reflect.ValueOf(format)
+}
+
+// TryAddFormat is like AddFormat but returns an error instead of panicking.
+// The error is *SignatureError for an invalid function, or *ConflictError if another format function is registered for the same type.
+func (teq *Teq) TryAddFormat(format any) error {
+	ty, err := formatTarget(format)
+	if err != nil {
+		return err
+	}
+	fn := reflect.ValueOf(format)
+	if err := conflict(ty, "format", fn, "format", teq.formats); err != nil {
+		return err
+	}
+	teq.formats[ty] = fn
+	return nil
+}
+
+// formatTarget validates the signature of a format function and returns the type to be formatted.
+func formatTarget(format any) (reflect.Type, error) {
 	ty := reflect.TypeOf(format)
-	if ty.Kind() != reflect.Func {
-		panic("format must be a function")
+	if ty == nil || ty.Kind() != reflect.Func {
+		return nil, signatureError("format", ty, "format must be a function")
 	}
-	if ty.NumIn() != 1 {
-		panic("format must have only one argument")
+	if ty.NumIn() == 2 && ty.In(0) != formatContextType {
+		return nil, signatureError("format", ty, "format with two arguments must take FormatContext as the first argument")
+	}
+	if ty.NumIn() != 1 && ty.NumIn() != 2 {
+		return nil, signatureError("format", ty, "format must have only one argument, optionally preceded by FormatContext")
 	}
 	if ty.NumOut() != 1 {
-		panic("format must have only one return value")
+		return nil, signatureError("format", ty, "format must have only one return value")
 	}
 	if ty.Out(0).Kind() != reflect.String {
-		panic("format must return string")
+		return nil, signatureError("format", ty, "format must return string")
 	}
-	teq.formats[ty.In(0)] = format
+	return ty.In(ty.NumIn() - 1), nil
+}
+
+// DisableAutoFormat makes values of the same type as sample not formatted by AutoFormat.
+// For a pointer type, pass a typed nil like (*T)(nil).
+func (teq *Teq) DisableAutoFormat(sample any) {
+	ty := reflect.TypeOf(sample)
+	if ty == nil {
+		panic("DisableAutoFormat requires a typed value")
+	}
+	teq.noAutoFormat[ty] = true
 }
 
 // AddEqual adds an equal function to Teq.
 // The equal function must have two arguments with the same type and one return value of bool.
 // If the passed equal function is not valid, it will panic.
 // The equal function will be used for equality check instead of the default equality check.
+// It takes precedence over a transform function registered for the same type,
+// and replaces an equal function registered for the same type before. Use TryAddEqual to detect them.
 func (teq *Teq) AddEqual(equal any) {
+	ty, err := equalTarget(equal)
+	if err != nil {
+		panic(err)
+	}
+	teq.equals[ty] = reflect.ValueOf(equal)
+}
+
+// TryAddEqual is like AddEqual but returns an error instead of panicking.
+// The error is *SignatureError for an invalid function, or *ConflictError if another equal function
+// or a transform function is registered for the same type.
+func (teq *Teq) TryAddEqual(equal any) error {
+	ty, err := equalTarget(equal)
+	if err != nil {
+		return err
+	}
+	fn := reflect.ValueOf(equal)
+	if err := conflict(ty, "equal", fn, "equal", teq.equals); err != nil {
+		return err
+	}
+	if err := conflict(ty, "equal", fn, "transform", teq.transforms); err != nil {
+		return err
+	}
+	teq.equals[ty] = fn
+	return nil
+}
+
+// equalTarget validates the signature of an equal function and returns the type to be compared.
+func equalTarget(equal any) (reflect.Type, error) {
 	ty := reflect.TypeOf(equal)
-	if ty.Kind() != reflect.Func {
-		panic("equal must be a function")
+	if ty == nil || ty.Kind() != reflect.Func {
+		return nil, signatureError("equal", ty, "equal must be a function")
 	}
 	if ty.NumIn() != 2 {
-		panic("equal must have two arguments")
+		return nil, signatureError("equal", ty, "equal must have two arguments")
 	}
 	if ty.In(0) != ty.In(1) {
-		panic("equal must have two arguments with the same type")
+		return nil, signatureError("equal", ty, "equal must have two arguments with the same type")
 	}
 	if ty.NumOut() != 1 {
-		panic("equal must have only one return value")
+		return nil, signatureError("equal", ty, "equal must have only one return value")
 	}
 	if ty.Out(0).Kind() != reflect.Bool {
-		panic("equal must return bool")
+		return nil, signatureError("equal", ty, "equal must return bool")
 	}
-	equalValue := reflect.ValueOf(equal)
-	reflectEqual := func(v1, v2 reflect.Value) bool {
-		return equalValue.Call([]reflect.Value{v1, v2})[0].Bool()
+	return ty.In(0), nil
+}
+
+// conflict returns *ConflictError if a function is registered for ty in m, which holds functions of the role registered.
+// Registering the same function again for the same role is not a conflict.
+func conflict(ty reflect.Type, role string, fn reflect.Value, registered string, m map[reflect.Type]reflect.Value) error {
+	r, ok := m[ty]
+	if !ok || role == registered && r.Pointer() == fn.Pointer() {
+		return nil
 	}
-	teq.equals[ty.In(0)] = reflectEqual
+	return &ConflictError{Type: ty, Role: role, Registered: registered}
+}
+
+type result struct {
+	equal bool
+	// exceeded is the path where MaxDepth was exceeded, if any.
+	exceeded *path
+	// panicked is the panic raised in a registered function, if any.
+	panicked *callbackPanic
+	// refused is the comparison refused by OpaqueFail, if any.
+	refused *refusal
 }
 
-func (teq Teq) equal(x, y any) bool {
+func (teq Teq) compare(x, y any) result {
 	if x == nil || y == nil {
-		return x == y
+		return result{equal: x == y}
 	}
 	v1 := reflect.ValueOf(x)
 	v2 := reflect.ValueOf(y)
-	return teq.reflectEqual(v1, v2)
+	c := newComparison()
+	eq := teq.deepValueEqual(v1, v2, c)
+	return result{equal: eq, exceeded: c.exceeded, panicked: c.panicked, refused: c.refused}
 }
 
+// reflectEqual is passed to akashi while reporting.
+// A panic in a registered function propagates as *callbackPanic to be recovered by report.
 func (teq Teq) reflectEqual(v1, v2 reflect.Value) bool {
-	if v1.Type() != v2.Type() {
-		return false
+	c := newComparison()
+	eq := teq.deepValueEqual(v1, v2, c)
+	if c.panicked != nil {
+		panic(c.panicked)
+	}
+	return eq
+}
+
+// labels returns ExpectedLabel and ActualLabel, or their defaults.
+func (teq Teq) labels() (string, string) {
+	e, a := teq.ExpectedLabel, teq.ActualLabel
+	if e == "" {
+		e = "expected"
+	}
+	if a == "" {
+		a = "actual"
+	}
+	return e, a
+}
+
+// withMessage puts the message given by msgAndArgs on top of the failure message.
+// If msgAndArgs starts with a string followed by arguments, it is formatted like fmt.Sprintf.
+func withMessage(failure string, msgAndArgs []any) string {
+	if len(msgAndArgs) == 0 {
+		return failure
+	}
+	var msg string
+	if format, ok := msgAndArgs[0].(string); ok && len(msgAndArgs) > 1 {
+		msg = fmt.Sprintf(format, msgAndArgs[1:]...)
+	} else {
+		msg = fmt.Sprint(msgAndArgs...)
+	}
+	return msg + "\n" + failure
+}
+
+func (teq Teq) maxDepthMessage(p *path) string {
+	hint := "consider raising MaxDepth or setting OnMaxDepth to MaxDepthUnknown."
+	if teq.OnMaxDepth == MaxDepthUnknown {
+		hint = "values beyond it are unknown, so the result is not conclusive. consider raising MaxDepth."
 	}
-	return teq.deepValueEqual(
-		v1, v2,
-		make(map[visit]bool),
-		0,
-	)
+	return fmt.Sprintf("maximum depth exceeded: MaxDepth is %d, reached at %s. %s", teq.MaxDepth, describePath(p), hint)
 }
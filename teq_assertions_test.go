@@ -0,0 +1,97 @@
+package teq_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seiyab/teq"
+)
+
+func TestAssertions(t *testing.T) {
+	assert := teq.New()
+	tq := teq.New()
+	tq.AddTransform(utc)
+
+	var nilPtr *int
+	d := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	jst := d.In(time.FixedZone("JST", 9*60*60))
+	errNotFound := errors.New("not found")
+	wrapped := fmt.Errorf("load config: %w", errNotFound)
+	_, pathErr := os.Open("/nonexistent")
+
+	tests := []struct {
+		name string
+		run  func(t teq.TestingT) bool
+		pass bool
+	}{
+		{"Nil nil", func(t teq.TestingT) bool { return tq.Nil(t, nil) }, true},
+		{"Nil typed nil", func(t teq.TestingT) bool { return tq.Nil(t, nilPtr) }, true},
+		{"Nil non-nil", func(t teq.TestingT) bool { return tq.Nil(t, 1) }, false},
+		{"NotNil non-nil", func(t teq.TestingT) bool { return tq.NotNil(t, ref(1)) }, true},
+		{"NotNil typed nil", func(t teq.TestingT) bool { return tq.NotNil(t, nilPtr) }, false},
+		{"Zero zero", func(t teq.TestingT) bool { return tq.Zero(t, struct{ A int }{}) }, true},
+		{"Zero non-zero", func(t teq.TestingT) bool { return tq.Zero(t, struct{ A int }{1}) }, false},
+		{"Len match", func(t teq.TestingT) bool { return tq.Len(t, []int{1, 2}, 2) }, true},
+		{"Len mismatch", func(t teq.TestingT) bool { return tq.Len(t, map[string]int{"a": 1}, 2) }, false},
+		{"Len no length", func(t teq.TestingT) bool { return tq.Len(t, 1, 0) }, false},
+		{"Empty nil slice", func(t teq.TestingT) bool { return tq.Empty(t, []int(nil)) }, true},
+		{"Empty empty string", func(t teq.TestingT) bool { return tq.Empty(t, "") }, true},
+		{"Empty zero struct", func(t teq.TestingT) bool { return tq.Empty(t, struct{ A int }{}) }, true},
+		{"Empty non-empty", func(t teq.TestingT) bool { return tq.Empty(t, []int{0}) }, false},
+		{"Contains substring", func(t teq.TestingT) bool { return tq.Contains(t, "hello", "ell") }, true},
+		{"Contains no substring", func(t teq.TestingT) bool { return tq.Contains(t, "hello", "bye") }, false},
+		{"Contains element with transform", func(t teq.TestingT) bool { return tq.Contains(t, []time.Time{jst}, d) }, true},
+		{"Contains map key", func(t teq.TestingT) bool { return tq.Contains(t, map[string]int{"a": 1}, "a") }, true},
+		{"Contains missing element", func(t teq.TestingT) bool { return tq.Contains(t, []int{1, 2}, 3) }, false},
+		{"Contains non-container", func(t teq.TestingT) bool { return tq.Contains(t, 1, 1) }, false},
+		{"ElementsMatch", func(t teq.TestingT) bool { return tq.ElementsMatch(t, []int{1, 2, 2}, []int{2, 1, 2}) }, true},
+		{"ElementsMatch with transform", func(t teq.TestingT) bool { return tq.ElementsMatch(t, []time.Time{d}, []time.Time{jst}) }, true},
+		{"ElementsMatch duplicates", func(t teq.TestingT) bool { return tq.ElementsMatch(t, []int{1, 2, 2}, []int{1, 1, 2}) }, false},
+		{"ErrorIs wrapped", func(t teq.TestingT) bool { return tq.ErrorIs(t, wrapped, errNotFound) }, true},
+		{"ErrorIs unrelated", func(t teq.TestingT) bool { return tq.ErrorIs(t, wrapped, fs.ErrExist) }, false},
+		{"ErrorAs match", func(t teq.TestingT) bool { var pe *fs.PathError; return tq.ErrorAs(t, pathErr, &pe) }, true},
+		{"ErrorAs mismatch", func(t teq.TestingT) bool { var pe *fs.PathError; return tq.ErrorAs(t, wrapped, &pe) }, false},
+		{"ErrorAs invalid target", func(t teq.TestingT) bool { return tq.ErrorAs(t, wrapped, nil) }, false},
+		{"Panics", func(t teq.TestingT) bool { return tq.Panics(t, func() { panic("boom") }) }, true},
+		{"Panics returned", func(t teq.TestingT) bool { return tq.Panics(t, func() {}) }, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mt := &mockT{}
+			ok := test.run(mt)
+			if ok != test.pass {
+				t.Errorf("expected %t, got %t", test.pass, ok)
+			}
+			if (len(mt.errors) == 0) != test.pass {
+				t.Errorf("expected errors only on failure, got %q", mt.errors)
+			}
+		})
+	}
+
+	t.Run("messages", func(t *testing.T) {
+		mt := &mockT{}
+		tq.Len(mt, []int{1}, 2)
+		tq.Contains(mt, []int{1, 2}, 3)
+		tq.ElementsMatch(mt, []int{1, 2, 2}, []int{1, 1, 2})
+		tq.ErrorIs(mt, wrapped, fs.ErrExist)
+		assert.Equal(t, []string{
+			"expected length 2, got 1: []int{1}",
+			"[]int{1, 2} doesn't contain int(3)",
+			"elements don't match\nmissing in actual:\n- 2\nunexpected in actual:\n+ 1",
+			`expected *errors.errorString("file already exists") in the error chain of *fmt.wrapError("load config: not found")`,
+		}, mt.errors)
+	})
+
+	t.Run("Zero reports diff", func(t *testing.T) {
+		mt := &mockT{}
+		tq.Zero(mt, 1)
+		if len(mt.errors) != 1 || !strings.HasPrefix(mt.errors[0], "not equal\n") {
+			t.Errorf("expected a report, got %q", mt.errors)
+		}
+	})
+}
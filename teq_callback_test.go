@@ -0,0 +1,72 @@
+package teq_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seiyab/teq"
+)
+
+type order struct {
+	ID    int
+	Price *int
+}
+
+func TestEqual_CallbackPanic(t *testing.T) {
+	t.Run("transform", func(t *testing.T) {
+		tq := teq.New()
+		tq.AddTransform(func(o *order) int {
+			return *o.Price
+		})
+		mt := &mockT{}
+		tq.Equal(mt, []*order{{1, ref(100)}}, []*order{{1, nil}})
+		if len(mt.errors) != 1 {
+			t.Fatalf("expected 1 error, got %d", len(mt.errors))
+		}
+		msg := mt.errors[0]
+		for _, s := range []string{
+			"panic in transform func(*teq_test.order) int at [0]: runtime error: invalid memory address or nil pointer dereference",
+			"argument 0: &teq_test.order{ID:1, Price:(*int)(nil)}",
+			"teq_callback_test.go",
+		} {
+			if !strings.Contains(msg, s) {
+				t.Errorf("expected %q to contain %q", msg, s)
+			}
+		}
+		if strings.Contains(msg, "please report issue") {
+			t.Errorf("panic in callback must not be reported as a bug: %q", msg)
+		}
+	})
+
+	t.Run("equal", func(t *testing.T) {
+		tq := teq.New()
+		tq.AddEqual(func(a, b order) bool {
+			return *a.Price == *b.Price
+		})
+		mt := &mockT{}
+		tq.NotEqual(mt, map[string]order{"x": {1, nil}}, map[string]order{"x": {2, nil}})
+		if len(mt.errors) != 1 {
+			t.Fatalf("expected 1 error, got %d", len(mt.errors))
+		}
+		prefix := `panic in equal func(teq_test.order, teq_test.order) bool at ["x"]: `
+		if !strings.HasPrefix(mt.errors[0], prefix) {
+			t.Errorf("expected %q to start with %q", mt.errors[0], prefix)
+		}
+	})
+
+	t.Run("format", func(t *testing.T) {
+		tq := teq.New()
+		tq.AddFormat(func(o order) string {
+			return string(rune(*o.Price))
+		})
+		mt := &mockT{}
+		tq.Equal(mt, order{1, nil}, order{2, nil})
+		if len(mt.errors) != 1 {
+			t.Fatalf("expected 1 error, got %d", len(mt.errors))
+		}
+		prefix := "panic in format func(teq_test.order) string: "
+		if !strings.HasPrefix(mt.errors[0], prefix) {
+			t.Errorf("expected %q to start with %q", mt.errors[0], prefix)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package teq_test
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/seiyab/teq"
+)
+
+func TestCollect(t *testing.T) {
+	assert := teq.New()
+
+	t.Run("Check", func(t *testing.T) {
+		mt := &mockT{}
+		c := assert.Collect(mt)
+		c.Equal(1, 1)
+		_, _, line, _ := runtime.Caller(0)
+		c.Equal(1, 2)
+		c.NotEqual("a", "a")
+		assert.Len(c, []int{1}, 2)
+		assert.Equal(t, []string(nil), mt.errors)
+
+		assert.Equal(t, false, c.Check())
+		expected := fmt.Sprintf(`3 failure(s)
+#1 at teq_collect_test.go:%d:
+    not equal
+    differences:
+    --- expected
+    +++ actual
+    - int(1)
+    + int(2)
+    delta: +1
+#2 at teq_collect_test.go:%d:
+    reflect.DeepEqual(expected, actual) == true.
+#3 at teq_collect_test.go:%d:
+    expected length 2, got 1: []int{1}`, line+1, line+2, line+3)
+		assert.Equal(t, []string{expected}, mt.errors)
+
+		assert.Equal(t, true, c.Check())
+		assert.Equal(t, 1, len(mt.errors))
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		ct := &cleanupT{}
+		c := assert.Collect(ct)
+		c.Equal("a", "b")
+		assert.Equal(t, []string(nil), ct.errors)
+		for _, f := range ct.cleanups {
+			f()
+		}
+		assert.Equal(t, 1, len(ct.errors))
+	})
+}
+
+type cleanupT struct {
+	mockT
+	cleanups []func()
+}
+
+func (t *cleanupT) Cleanup(f func()) {
+	t.cleanups = append(t.cleanups, f)
+}
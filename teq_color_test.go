@@ -0,0 +1,49 @@
+package teq_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/seiyab/teq"
+)
+
+func TestEqual_Color(t *testing.T) {
+	tq := teq.New()
+	tq.AddFormat(func(kind reflect.Kind) string {
+		return kind.String()
+	})
+
+	t.Run("always", func(t *testing.T) {
+		tq := tq
+		tq.Color = teq.ColorAlways
+		mt := &mockT{}
+		tq.Equal(mt, reflect.Int, reflect.String)
+		expected := strings.Join([]string{
+			"not equal",
+			"differences:",
+			"\x1b[1m\x1b[31m--- expected\x1b[0m",
+			"\x1b[1m\x1b[32m+++ actual\x1b[0m",
+			"\x1b[31m- reflect.Kind(\"\x1b[7mint\x1b[27m\")\x1b[0m",
+			"\x1b[32m+ reflect.Kind(\"\x1b[7mstring\x1b[27m\")\x1b[0m",
+		}, "\n")
+		assert := teq.New()
+		assert.Equal(t, []string{expected}, mt.errors)
+	})
+
+	t.Run("auto", func(t *testing.T) {
+		for _, env := range []string{"NO_COLOR", "CI"} {
+			t.Run(env, func(t *testing.T) {
+				t.Setenv(env, "1")
+				mt := &mockT{}
+				tq.Equal(mt, reflect.Int, reflect.String)
+				if len(mt.errors) != 1 {
+					t.Fatalf("expected 1 error, got %d", len(mt.errors))
+				}
+				if strings.Contains(mt.errors[0], "\x1b[") {
+					t.Errorf("expected no color with %s, got %q", env, mt.errors[0])
+				}
+			})
+		}
+	})
+}
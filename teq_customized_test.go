@@ -1,6 +1,8 @@
 package teq_test
 
 import (
+	"errors"
+	"fmt"
 	"math"
 	"reflect"
 	"testing"
@@ -84,6 +86,25 @@ func TestEqual_Customized(t *testing.T) {
 			d.NotEqual(t, d1, d2)
 			c.Equal(t, d1, d2)
 		})
+
+		t.Run("array of a byte-sized type", func(t *testing.T) {
+			type level uint8
+			d := teq.New()
+			c := teq.New()
+			c.AddEqual(func(a, b level) bool { return true })
+
+			d.NotEqual(t, [2]level{1, 2}, [2]level{3, 4})
+			c.Equal(t, [2]level{1, 2}, [2]level{3, 4})
+		})
+
+		t.Run("byte array", func(t *testing.T) {
+			d := teq.New()
+			c := teq.New()
+			c.AddEqual(func(a, b byte) bool { return a%2 == b%2 })
+
+			d.NotEqual(t, [2]byte{1, 2}, [2]byte{3, 4})
+			c.Equal(t, [2]byte{1, 2}, [2]byte{3, 4})
+		})
 	})
 }
 
@@ -196,8 +217,167 @@ differences:
 		}
 		assert.Equal(t, expected, mt.errors[0])
 	})
+
+	t.Run("simple messages", func(t *testing.T) {
+		tq := teq.New()
+		tq.AddFormat(func(kind reflect.Kind) string {
+			return kind.String()
+		})
+
+		tq.AddTransform(utc)
+		tq.AddFormat(func(d time.Time) string {
+			return d.Format(time.RFC3339)
+		})
+
+		mt := &mockT{}
+		d := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+		tq.NotEqual(mt, []time.Time{d}, []time.Time{d.In(time.FixedZone("JST", 9*60*60))})
+		expected := `expected []time.Time{time.Time("2000-01-01T00:00:00Z")} != []time.Time{time.Time("2000-01-01T09:00:00+09:00")}`
+		assert.Equal(t, []string{expected}, mt.errors)
+
+		mt = &mockT{}
+		tq.Equal(mt, reflect.Int, nil)
+		expected = `expected reflect.Kind("int"), got nil`
+		assert.Equal(t, []string{expected}, mt.errors)
+
+		mt = &mockT{}
+		tq.Equal(mt, reflect.Int, "int")
+		expected = `type mismatch
+--- expected: reflect.Kind
++++ actual: string
+- reflect.Kind("int")
++ string("int")`
+		assert.Equal(t, []string{expected}, mt.errors)
+	})
+
+	t.Run("FormatContext", func(t *testing.T) {
+		type money struct {
+			cents int
+		}
+		type envelope struct {
+			Label string
+			Money money
+		}
+		type ledger struct {
+			Envelopes []envelope
+		}
+		tq := teq.New()
+		tq.Reporter = teq.PathReporter{}
+		tq.AddFormat(func(m money) string {
+			return fmt.Sprintf("$%d.%02d", m.cents/100, m.cents%100)
+		})
+		tq.AddFormat(func(ctx teq.FormatContext, e envelope) string {
+			return fmt.Sprintf("%s at %s (depth %d): %s", e.Label, ctx.Path, ctx.Depth, ctx.Format(e.Money))
+		})
+
+		mt := &mockT{}
+		tq.Equal(mt,
+			ledger{[]envelope{{"food", money{30_00}}, {"rent", money{100_00}}}},
+			ledger{[]envelope{{"food", money{30_00}}, {"rent", money{120_50}}}},
+		)
+		expected := `not equal: 1 difference(s)
+.Envelopes[1]: expected teq_test.envelope("rent at .Envelopes[1] (depth 2): teq_test.money(\"$100.00\")"), actual teq_test.envelope("rent at .Envelopes[1] (depth 2): teq_test.money(\"$120.50\")")`
+		assert.Equal(t, []string{expected}, mt.errors)
+	})
+
+	t.Run("FormatContext in UnifiedReporter", func(t *testing.T) {
+		type money int
+		type envelope struct {
+			Label  string
+			Amount money
+		}
+		tq := teq.New()
+		var contexts []teq.FormatContext
+		tq.AddFormat(func(ctx teq.FormatContext, m money) string {
+			contexts = append(contexts, ctx)
+			return fmt.Sprintf("$%d", m)
+		})
+		tq.AddFormat(func(ctx teq.FormatContext, e envelope) string {
+			contexts = append(contexts, ctx)
+			return e.Label + " " + ctx.Format(e.Amount)
+		})
+
+		mt := &mockT{}
+		tq.Equal(mt, []envelope{{"food", 1}, {"rent", 2}}, []envelope{{"food", 1}, {"fees", 2}})
+		if len(mt.errors) != 1 {
+			t.Fatalf("expected 1 error, got %d", len(mt.errors))
+		}
+		if len(contexts) == 0 {
+			t.Fatal("expected the format to be called")
+		}
+		for _, ctx := range contexts {
+			if ctx.Path != "" || ctx.Depth != 0 {
+				// Children of a value at an unknown location are at unknown locations too.
+				t.Errorf("expected the location to be unknown inside the diff, got path %q and depth %d", ctx.Path, ctx.Depth)
+			}
+		}
+	})
 }
 
 func utc(d time.Time) time.Time {
 	return d.UTC()
 }
+
+type celsius float64
+
+func (c celsius) String() string {
+	return fmt.Sprintf("%.1f°C", float64(c))
+}
+
+func TestEqual_AutoFormat(t *testing.T) {
+	assert := teq.New()
+	type reading struct {
+		At    time.Time
+		Temp  celsius
+		Err   error
+		Since *time.Duration
+	}
+	d := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := reading{d, 20, nil, nil}
+	b := reading{d.Add(time.Minute), 21.5, errors.New("sensor offline"), nil}
+
+	newTeq := func() teq.Teq {
+		tq := teq.New()
+		tq.AutoFormat = true
+		tq.Reporter = teq.PathReporter{}
+		return tq
+	}
+
+	t.Run("methods", func(t *testing.T) {
+		mt := &mockT{}
+		newTeq().Equal(mt, a, b)
+		expected := `not equal: 3 difference(s)
+.At: expected time.Time("2000-01-01 00:00:00 +0000 UTC"), actual time.Time("2000-01-01 00:01:00 +0000 UTC")
+.Temp: expected teq_test.celsius("20.0°C"), actual teq_test.celsius("21.5°C")
+.Err: expected error(nil), actual *errors.errorString("sensor offline")`
+		assert.Equal(t, []string{expected}, mt.errors)
+	})
+
+	t.Run("registered format first", func(t *testing.T) {
+		tq := newTeq()
+		tq.AddFormat(func(c celsius) string {
+			return fmt.Sprintf("%.0fK", float64(c)+273.15)
+		})
+		mt := &mockT{}
+		tq.Equal(mt, a.Temp, b.Temp)
+		assert.Equal(t, []string{`not equal: 1 difference(s)
+(root): expected teq_test.celsius("293K"), actual teq_test.celsius("295K")`}, mt.errors)
+	})
+
+	t.Run("opt out", func(t *testing.T) {
+		tq := newTeq()
+		tq.DisableAutoFormat(celsius(0))
+		mt := &mockT{}
+		tq.Equal(mt, a.Temp, b.Temp)
+		assert.Equal(t, []string{`not equal: 1 difference(s)
+(root): expected 20, actual 21.5`}, mt.errors)
+	})
+
+	t.Run("nil pointer", func(t *testing.T) {
+		one := time.Second
+		mt := &mockT{}
+		newTeq().Equal(mt, reading{Since: nil}, reading{Since: &one})
+		assert.Equal(t, []string{`not equal: 1 difference(s)
+.Since: expected *time.Duration(nil), actual *time.Duration("1s")`}, mt.errors)
+	})
+}
@@ -0,0 +1,88 @@
+package teq_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seiyab/teq"
+)
+
+type ring struct {
+	V    int
+	Next *ring
+}
+
+// newRing returns a cycle of n nodes with the same value.
+func newRing(n int) *ring {
+	head := &ring{V: 1}
+	last := head
+	for i := 1; i < n; i++ {
+		last.Next = &ring{V: 1}
+		last = last.Next
+	}
+	last.Next = head
+	return head
+}
+
+func TestEqual_StrictCycles(t *testing.T) {
+	assert := teq.New()
+	strict := teq.New()
+	strict.StrictCycles = true
+
+	assert.Equal(t, true, teq.New().Equal(&mockT{}, newRing(1), newRing(2)))
+	assert.Equal(t, false, strict.Equal(&mockT{}, newRing(1), newRing(2)))
+	assert.Equal(t, true, strict.Equal(&mockT{}, newRing(3), newRing(3)))
+
+	t.Run("map cycle", func(t *testing.T) {
+		strict := strict
+		strict.Reporter = teq.PathReporter{}
+		m1 := map[string]any{}
+		m1["self"] = m1
+		m2 := map[string]any{}
+		m3 := map[string]any{"self": m2}
+		m2["self"] = m3
+		m4 := map[string]any{}
+		m4["self"] = m4
+		assert.Equal(t, true, strict.Equal(&mockT{}, m1, m4))
+		// Unrolling a cycle changes its shape.
+		assert.Equal(t, false, strict.Equal(&mockT{}, m1, map[string]any{"self": m1}))
+		assert.Equal(t, false, strict.Equal(&mockT{}, m1, m2))
+	})
+
+	t.Run("report", func(t *testing.T) {
+		tq := teq.New()
+		tq.StrictCycles = true
+		tq.Reporter = teq.PathReporter{}
+		mt := &mockT{}
+		tq.Equal(mt, newRing(1), newRing(2))
+		expected := `not equal: 1 difference(s)
+.Next: expected &teq_test.ring{V: 1, Next: &<cycle to .Next>}, actual &teq_test.ring{V: 1, Next: &teq_test.ring{V: 1, Next: &<cycle to .Next>}}`
+		assert.Equal(t, []string{expected}, mt.errors)
+
+		mt = &mockT{}
+		tq.Reporter = nil
+		tq.Equal(mt, newRing(1), newRing(2))
+		note := "references are shared differently at .Next: expected same reference as (root), actual a new reference"
+		if len(mt.errors) != 1 || !strings.HasSuffix(mt.errors[0], "\n"+note) {
+			t.Errorf("expected a note %q, got %q", note, mt.errors)
+		}
+	})
+
+	t.Run("zero size", func(t *testing.T) {
+		assert.Equal(t, true, strict.Equal(&mockT{}, [][]int{{}, {}}, [][]int{make([]int, 0, 1), make([]int, 0, 1)}))
+		type empty struct{}
+		assert.Equal(t, true, strict.Equal(&mockT{}, []*empty{{}, {}}, []*empty{new(empty), new(empty)}))
+	})
+
+	t.Run("shared without cycles", func(t *testing.T) {
+		x := &ring{V: 1}
+		assert.Equal(t, true, teq.New().Equal(&mockT{}, []*ring{x, x}, []*ring{{V: 1}, {V: 1}}))
+
+		mt := &mockT{}
+		strict.Equal(mt, []*ring{x, x}, []*ring{{V: 1}, {V: 1}})
+		note := "references are shared differently at [1]: expected same reference as [0], actual a new reference"
+		if len(mt.errors) != 1 || !strings.HasSuffix(mt.errors[0], "\n"+note) {
+			t.Errorf("expected a note %q, got %q", note, mt.errors)
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"math"
 	"strings"
 	"testing"
 
@@ -74,15 +75,22 @@ func TestEqual(t *testing.T) {
 func primitives() []test {
 	return []test{
 		{1, 1, nil},
-		{1, 2, []string{"expected 1, got 2"}},
+		{1, 2, []string{scalarReport("int(1)", "int(2)", "+1")}},
 		{uint8(1), uint8(1), nil},
-		{uint8(1), uint8(2), []string{"expected 1, got 2"}},
+		{uint8(1), uint8(2), []string{scalarReport("uint8(1)", "uint8(2)", "+1")}},
+		{uint8(2), uint8(1), []string{scalarReport("uint8(2)", "uint8(1)", "-1")}},
 		{1.5, 1.5, nil},
-		{1.5, 2.5, []string{"expected 1.5, got 2.5"}},
+		{1.5, 2.5, []string{scalarReport("float64(1.5)", "float64(2.5)", "+1")}},
+		{math.Nextafter(0.3, 1), 0.3, []string{scalarReport("float64(0.30000000000000004)", "float64(0.3)", "-5.551115123125783e-17")}},
+		{true, false, []string{scalarReport("bool(true)", "bool(false)", "")}},
 		{"a", "a", nil},
 		{"a", "b", []string{"expected a, got b"}},
 
-		{"a", 1, []string{"expected a, got 1"}},
+		{"a", 1, []string{`type mismatch
+--- expected: string
++++ actual: int
+- string("a")
++ int(1)`}},
 	}
 }
 
@@ -108,7 +116,11 @@ differences:
 -   i: 1,
 +   i: 2,
   }`}},
-		{s{1}, anotherS{1}, []string{"expected {1}, got {1}"}},
+		{s{1}, anotherS{1}, []string{`type mismatch
+--- expected: github.com/seiyab/teq_test.s
++++ actual: github.com/seiyab/teq_test.anotherS
+- teq_test.s{i: 1}
++ teq_test.anotherS{i: 1}`}},
 
 		{withPointer{ref(1)}, withPointer{ref(1)}, nil},
 		{withPointer{ref(1)}, withPointer{ref(2)}, []string{`not equal
@@ -170,6 +182,21 @@ differences:
 +++ actual
   map[string]int{
 -   "a": 0,
+  }`}},
+		{map[float64]int{math.NaN(): 1}, map[float64]int{}, []string{`not equal
+differences:
+--- expected
++++ actual
+  map[float64]int{
+-   NaN: 1,
+  }`}},
+		{map[float64]int{math.NaN(): 1}, map[float64]int{math.NaN(): 2}, []string{`not equal
+differences:
+--- expected
++++ actual
+  map[float64]int{
+-   NaN: 1,
++   NaN: 2,
   }`}},
 
 		{
@@ -260,7 +287,11 @@ func channels() []test {
 	c2 := make(chan int)
 	return []test{
 		{c1, c1, nil},
-		{c1, c2, []string{fmt.Sprintf("expected %p, got %p", c1, c2)}},
+		{c1, c2, []string{scalarReport(
+			fmt.Sprintf("chan int at [%p]", c1),
+			fmt.Sprintf("chan int at [%p]", c2),
+			"",
+		)}},
 		{[]chan int{c1}, []chan int{c1}, nil},
 		{[]chan int{c1}, []chan int{c2}, []string{
 			strings.Join([]string{
@@ -349,6 +380,21 @@ differences:
 	}
 }
 
+func scalarReport(expected, actual, delta string) string {
+	lines := []string{
+		"not equal",
+		"differences:",
+		"--- expected",
+		"+++ actual",
+		"- " + expected,
+		"+ " + actual,
+	}
+	if delta != "" {
+		lines = append(lines, "delta: "+delta)
+	}
+	return strings.Join(lines, "\n")
+}
+
 func ref[T any](v T) *T {
 	return &v
 }
@@ -0,0 +1,110 @@
+package teq_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seiyab/teq"
+)
+
+type linkedList struct {
+	v    int
+	next *linkedList
+}
+
+func newLinkedList(n int) *linkedList {
+	var l *linkedList
+	for i := n - 1; i >= 0; i-- {
+		l = &linkedList{v: i, next: l}
+	}
+	return l
+}
+
+func TestEqual_MaxDepth(t *testing.T) {
+	t.Run("fail", func(t *testing.T) {
+		tq := teq.New()
+		tq.MaxDepth = 10
+		mt := &mockT{}
+		tq.Equal(mt, newLinkedList(20), newLinkedList(20))
+		if len(mt.errors) != 1 {
+			t.Fatalf("expected 1 error, got %d", len(mt.errors))
+		}
+		if !strings.HasPrefix(mt.errors[0], "maximum depth exceeded: MaxDepth is 10, reached at .next.next") {
+			t.Errorf("unexpected message: %q", mt.errors[0])
+		}
+		if strings.Contains(mt.errors[0], "please report issue") {
+			t.Errorf("depth overflow must not be reported as a bug: %q", mt.errors[0])
+		}
+
+		mt = &mockT{}
+		tq.NotEqual(mt, newLinkedList(20), newLinkedList(20))
+		if len(mt.errors) != 1 {
+			t.Fatalf("expected 1 error, got %d", len(mt.errors))
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		tq := teq.New()
+		tq.MaxDepth = 10
+		tq.OnMaxDepth = teq.MaxDepthUnknown
+
+		mt := &mockT{}
+		if tq.Equal(mt, newLinkedList(20), newLinkedList(20)) {
+			t.Error("expected false for unknown result")
+		}
+		if len(mt.errors) != 0 {
+			t.Fatalf("expected no errors, got %q", mt.errors)
+		}
+		if len(mt.logs) != 1 || !strings.HasPrefix(mt.logs[0], "maximum depth exceeded") ||
+			strings.Contains(mt.logs[0], "setting OnMaxDepth") {
+			t.Errorf("expected a log about maximum depth, got %q", mt.logs)
+		}
+
+		mt = &mockT{}
+		if tq.NotEqual(mt, newLinkedList(20), newLinkedList(20)) {
+			t.Error("expected false for unknown result")
+		}
+		if len(mt.errors) != 0 {
+			t.Fatalf("expected no errors, got %q", mt.errors)
+		}
+
+		a := newLinkedList(20)
+		b := newLinkedList(20)
+		b.v = 100
+		mt = &mockT{}
+		tq.NotEqual(mt, a, b)
+		if len(mt.errors) != 0 || len(mt.logs) != 0 {
+			t.Errorf("expected definite difference to be found, got errors %q, logs %q", mt.errors, mt.logs)
+		}
+	})
+}
+
+func TestEqual_Deep(t *testing.T) {
+	tq := teq.New()
+	tq.MaxDepth = 0
+
+	const n = 100_000
+	tq.Equal(t, newLinkedList(n), newLinkedList(n))
+
+	a := newLinkedList(n)
+	b := newLinkedList(n)
+	last := b
+	for last.next != nil {
+		last = last.next
+	}
+	last.v = -1
+	tq.NotEqual(t, a, b)
+
+	t.Run("cyclic", func(t *testing.T) {
+		a := newLinkedList(n)
+		b := newLinkedList(n)
+		for _, l := range []*linkedList{a, b} {
+			last := l
+			for last.next != nil {
+				last = last.next
+			}
+			last.next = l
+		}
+		tq.Equal(t, a, b)
+	})
+}
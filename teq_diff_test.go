@@ -1,7 +1,9 @@
 package teq_test
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -41,6 +43,271 @@ differences:
 	assert.Equal(t, expected, mt.errors[0])
 }
 
+func TestEqual_Truncation(t *testing.T) {
+	lines := func(n int, change map[int]string) string {
+		var ls []string
+		for i := 0; i < n; i++ {
+			if c, ok := change[i]; ok {
+				ls = append(ls, c)
+				continue
+			}
+			ls = append(ls, fmt.Sprintf("line %d", i))
+		}
+		return strings.Join(ls, "\n")
+	}
+	a := lines(20, nil)
+	b := lines(20, map[int]string{3: "changed 3", 12: "changed 12", 17: "changed 17"})
+
+	t.Run("ContextLines", func(t *testing.T) {
+		tq := teq.New()
+		tq.ContextLines = 1
+		mt := &mockT{}
+		tq.Equal(mt, a, b)
+		expected := `not equal
+differences:
+--- expected
++++ actual
+  string(
+:
+    line 2
+-   line 3
++   changed 3
+    ^^^^^^^
+    line 4
+:
+    line 11
+-   line 12
++   changed 12
+    ^^^^^^^
+    line 13
+:
+    line 16
+-   line 17
++   changed 17
+    ^^^^^^^
+    line 18
+:
+  )`
+		assert := teq.New()
+		assert.Equal(t, []string{expected}, mt.errors)
+	})
+
+	t.Run("MaxDifferences", func(t *testing.T) {
+		tq := teq.New()
+		tq.ContextLines = 0
+		tq.MaxDifferences = 1
+		mt := &mockT{}
+		tq.Equal(mt, a, b)
+		expected := `not equal
+differences:
+--- expected
++++ actual
+  string(
+:
+-   line 3
++   changed 3
+    ^^^^^^^
+:
+… 2 more differences omitted`
+		assert := teq.New()
+		assert.Equal(t, []string{expected}, mt.errors)
+
+		tq.Reporter = teq.PathReporter{}
+		mt = &mockT{}
+		tq.Equal(mt, []int{1, 2, 3}, []int{4, 5, 6})
+		expected = `not equal: 3 difference(s)
+[0]: expected 1, actual 4
+… 2 more differences omitted`
+		assert.Equal(t, []string{expected}, mt.errors)
+	})
+
+	t.Run("MaxReportSize", func(t *testing.T) {
+		tq := teq.New()
+		tq.ContextLines = 0
+		tq.MaxReportSize = 60
+		mt := &mockT{}
+		tq.Equal(mt, a, b)
+		expected := `not equal
+differences:
+--- expected
++++ actual
+  string(
+:
+… report truncated, 125 more bytes omitted`
+		assert := teq.New()
+		assert.Equal(t, []string{expected}, mt.errors)
+	})
+}
+
+func TestEqual_StringWhitespace(t *testing.T) {
+	t.Run("changed characters", func(t *testing.T) {
+		tq := teq.New()
+		mt := &mockT{}
+		tq.Equal(mt, "a\nfoo bar baz\nc", "a\nfoo bax baz\nc")
+		expected := `not equal
+differences:
+--- expected
++++ actual
+  string(
+    a
+-   foo bar baz
++   foo bax baz
+          ^
+    c
+  )`
+		assert := teq.New()
+		assert.Equal(t, []string{expected}, mt.errors)
+	})
+
+	t.Run("ShowWhitespace", func(t *testing.T) {
+		tq := teq.New()
+		tq.ShowWhitespace = true
+		mt := &mockT{}
+		tq.Equal(mt, "a \n\tb\r\nc\x00\xff", "a\n    b\nc\x00\xff")
+		expected := `not equal
+differences:
+--- expected
++++ actual
+  string(
+-   a·
+-   →b␍
++   a
++   ····b
+    c\x00\xff
+  )`
+		assert := teq.New()
+		assert.Equal(t, []string{expected}, mt.errors)
+	})
+
+	t.Run("single line", func(t *testing.T) {
+		tq := teq.New()
+		mt := &mockT{}
+		tq.Equal(mt, "a\tb", "a  b")
+		assert := teq.New()
+		assert.Equal(t, []string{`expected "a\tb", got "a  b"`}, mt.errors)
+	})
+}
+
+func TestEqual_Bytes(t *testing.T) {
+	t.Run("hexdump", func(t *testing.T) {
+		a := []byte("Hello, world! This is a hexdump test for teq.\x00\x01\x02 and more bytes to fill up lines.")
+		b := append([]byte(nil), a...)
+		b[20] = 'X'
+		b = append(b, '!')
+		tq := teq.New()
+		tq.ContextLines = 1
+		mt := &mockT{}
+		tq.Equal(mt, a, b)
+		expected := `not equal
+differences:
+--- expected
++++ actual
+  []uint8 (len 81 vs 82)
+  00000000  48 65 6c 6c 6f 2c 20 77  6f 72 6c 64 21 20 54 68  |Hello, world! Th|
+- 00000010  69 73 20 69 73 20 61 20  68 65 78 64 75 6d 70 20  |is is a hexdump |
++ 00000010  69 73 20 69 58 20 61 20  68 65 78 64 75 6d 70 20  |is iX a hexdump |
+                        ^^                                         ^
+  00000020  74 65 73 74 20 66 6f 72  20 74 65 71 2e 00 01 02  |test for teq....|
+:
+  00000040  74 6f 20 66 69 6c 6c 20  75 70 20 6c 69 6e 65 73  |to fill up lines|
+- 00000050  2e                                                |.               |
++ 00000050  2e 21                                             |.!              |
+               ^^                                               ^`
+		assert := teq.New()
+		assert.Equal(t, []string{expected}, mt.errors)
+	})
+
+	t.Run("array", func(t *testing.T) {
+		tq := teq.New()
+		mt := &mockT{}
+		tq.Equal(mt, [4]byte{1, 2, 3, 4}, [4]byte{1, 2, 3, 5})
+		expected := `not equal
+differences:
+--- expected
++++ actual
+  [4]uint8 (len 4)
+- 00000000  01 02 03 04                                       |....            |
++ 00000000  01 02 03 05                                       |....            |
+                      ^`
+		assert := teq.New()
+		assert.Equal(t, []string{expected}, mt.errors)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		type envelope struct {
+			ID      int
+			Payload []byte
+			Hash    [4]byte
+		}
+		tq := teq.New()
+		mt := &mockT{}
+		tq.Equal(mt,
+			envelope{1, []byte("hello"), [4]byte{1, 2, 3, 4}},
+			envelope{1, []byte("hallo"), [4]byte{1, 2, 3, 5}},
+		)
+		expected := `
+bytes at .Payload:
+  []uint8 (len 5)
+- 00000000  68 65 6c 6c 6f                                    |hello           |
++ 00000000  68 61 6c 6c 6f                                    |hallo           |
+                ^                                               ^
+bytes at .Hash:
+  [4]uint8 (len 4)
+- 00000000  01 02 03 04                                       |....            |
++ 00000000  01 02 03 05                                       |....            |
+                      ^`
+		if len(mt.errors) != 1 || !strings.HasSuffix(mt.errors[0], expected) {
+			t.Errorf("expected hexdumps of nested bytes at the end, got %q", mt.errors)
+		}
+	})
+
+	t.Run("BytesAsText", func(t *testing.T) {
+		tq := teq.New()
+		tq.BytesAsText = true
+		mt := &mockT{}
+		tq.Equal(mt, []byte("a\nb\nc"), []byte("a\nB\nc"))
+		expected := `not equal
+differences:
+--- expected
++++ actual
+  []uint8(
+    a
+-   b
++   B
+    ^
+    c
+  )`
+		assert := teq.New()
+		assert.Equal(t, []string{expected}, mt.errors)
+	})
+}
+
+func TestEqual_TypeMismatch(t *testing.T) {
+	assert := teq.New()
+	t.Run("top level", func(t *testing.T) {
+		mt := &mockT{}
+		assert.Equal(mt, 1, int64(1))
+		expected := `type mismatch
+--- expected: int
++++ actual: int64
+- int(1)
++ int64(1)`
+		assert.Equal(t, []string{expected}, mt.errors)
+	})
+
+	t.Run("nested interface", func(t *testing.T) {
+		mt := &mockT{}
+		assert.Equal(mt, map[string]any{"a": 1, "b": "x"}, map[string]any{"a": int64(1), "b": "x"})
+		if len(mt.errors) != 1 {
+			t.Fatalf("expected 1 error, got %d", len(mt.errors))
+		}
+		note := `dynamic types differ at ["a"]: int vs int64`
+		if !strings.HasSuffix(mt.errors[0], "\n"+note) {
+			t.Errorf("expected %q to end with %q", mt.errors[0], note)
+		}
+	})
+}
+
 func TestEqual_Format(t *testing.T) {
 	assert := teq.New()
 	t.Run("array", func(t *testing.T) {
@@ -0,0 +1,129 @@
+package teq_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/seiyab/teq"
+)
+
+type codeError struct {
+	code int
+}
+
+func (e codeError) Error() string {
+	return fmt.Sprintf("code %d", e.code)
+}
+
+type tracedError struct {
+	msg   string
+	stack []uintptr
+}
+
+func (e *tracedError) Error() string {
+	return e.msg
+}
+
+func TestEqual_ErrorEquality(t *testing.T) {
+	errBase := errors.New("not found")
+	type result struct {
+		Err error
+	}
+
+	tests := []struct {
+		name     string
+		mode     teq.ErrorMode
+		expected any
+		actual   any
+		equal    bool
+	}{
+		{"structural same", teq.ErrorStructural, errBase, errBase, true},
+		{"structural different stacks", teq.ErrorStructural, &tracedError{"x", []uintptr{1}}, &tracedError{"x", []uintptr{2}}, false},
+		{"chain wrapped", teq.ErrorChain, errBase, fmt.Errorf("load: %w", errBase), true},
+		{"chain in field", teq.ErrorChain, result{errBase}, result{fmt.Errorf("load: %w", errBase)}, true},
+		{"chain unrelated", teq.ErrorChain, errBase, errors.New("not found"), false},
+		{"chain nil", teq.ErrorChain, result{nil}, result{errBase}, false},
+		{"chain both nil", teq.ErrorChain, result{nil}, result{nil}, true},
+		{"message", teq.ErrorMessage, fmt.Errorf("a: %w", errBase), fmt.Errorf("a: %w", errors.New("not found")), true},
+		{"message different types", teq.ErrorMessage, result{codeError{1}}, result{errors.New("code 1")}, true},
+		{"message different stacks", teq.ErrorMessage, &tracedError{"x", []uintptr{1}}, &tracedError{"x", []uintptr{2}}, true},
+		{"message differs", teq.ErrorMessage, errBase, errors.New("gone"), false},
+		{"type and message", teq.ErrorTypeMessage, result{codeError{1}}, result{codeError{1}}, true},
+		{"type differs", teq.ErrorTypeMessage, result{codeError{1}}, result{errors.New("code 1")}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tq := teq.New()
+			tq.ErrorEquality = test.mode
+			mt := &mockT{}
+			if got := tq.Equal(mt, test.expected, test.actual); got != test.equal {
+				t.Errorf("expected %t, got %t: %q", test.equal, got, mt.errors)
+			}
+		})
+	}
+
+	t.Run("registered equal first", func(t *testing.T) {
+		tq := teq.New()
+		tq.ErrorEquality = teq.ErrorMessage
+		tq.AddEqual(func(a, b codeError) bool { return true })
+		assert := teq.New()
+		assert.Equal(t, true, tq.Equal(&mockT{}, codeError{1}, codeError{2}))
+		assert.Equal(t, true, tq.Equal(&mockT{}, result{codeError{1}}, result{codeError{2}}))
+		assert.Equal(t, false, tq.Equal(&mockT{}, result{codeError{1}}, result{errors.New("code 2")}))
+	})
+}
+
+func TestEqual_ErrorChainDiff(t *testing.T) {
+	assert := teq.New()
+	tq := teq.New()
+	tq.ErrorEquality = teq.ErrorMessage
+
+	mt := &mockT{}
+	tq.Equal(mt,
+		fmt.Errorf("load config: %w", &fs.PathError{Op: "open", Path: "a.yaml", Err: fs.ErrNotExist}),
+		fmt.Errorf("load config: %w", codeError{3}),
+	)
+	expected := `not equal
+differences:
+--- expected
++++ actual
+  error chain:
+-   *fmt.wrapError("load config: open a.yaml: file does not exist")
+-     *fs.PathError("open a.yaml: file does not exist")
+-       *errors.errorString("file does not exist")
++   *fmt.wrapError("load config: code 3")
+                                 ^^^^^^
++     teq_test.codeError("code 3")
+      ^^^^^^^^^^^^^^^^^^^^^^^^^^`
+	assert.Equal(t, []string{expected}, mt.errors)
+
+	t.Run("falls back to the ordinary diff", func(t *testing.T) {
+		errBase := errors.New("not found")
+		tests := []struct {
+			name             string
+			mode             teq.ErrorMode
+			expected, actual error
+		}{
+			{"structural", teq.ErrorStructural, &tracedError{"x", []uintptr{1}}, &tracedError{"x", []uintptr{2}}},
+			{"same chains", teq.ErrorChain, errBase, errors.New("not found")},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				tq := teq.New()
+				tq.ErrorEquality = test.mode
+				mt := &mockT{}
+				tq.Equal(mt, test.expected, test.actual)
+				if len(mt.errors) != 1 {
+					t.Fatalf("expected 1 error, got %d", len(mt.errors))
+				}
+				msg := mt.errors[0]
+				if strings.Contains(msg, "error chain:") || !strings.Contains(msg, "\n-") || !strings.Contains(msg, "\n+") {
+					t.Errorf("expected the ordinary diff, got %q", msg)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package teq_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seiyab/teq"
+)
+
+func TestExplain(t *testing.T) {
+	type item struct {
+		Name string
+		At   time.Time
+	}
+	tq := teq.New()
+	tq.AddTransform(utc)
+
+	secondsEastOfUTC := int((8 * time.Hour).Seconds())
+	beijing := time.FixedZone("Beijing Time", secondsEastOfUTC)
+	d1 := time.Date(2000, 2, 1, 12, 30, 0, 0, time.UTC)
+	d2 := time.Date(2000, 2, 1, 20, 30, 0, 0, beijing)
+
+	t.Run("tree", func(t *testing.T) {
+		a := []item{{"a", d1}, {"b", d1}}
+		b := []item{{"a", d2}, {"c", d2}}
+		expected := `(root) []teq_test.item: slice -> not equal
+  [0] teq_test.item: struct -> equal
+    [0].Name string: string -> equal
+    [0].At time.Time: transform func(time.Time) time.Time, struct -> equal
+      [0].At.wall uint64: uint64 -> equal
+      [0].At.ext int64: int64 -> equal
+      [0].At.loc *time.Location: ptr -> equal
+  [1] teq_test.item: struct -> not equal
+    [1].Name string: string -> not equal
+    [1].At time.Time: transform func(time.Time) time.Time, struct -> equal
+      [1].At.wall uint64: uint64 -> equal
+      [1].At.ext int64: int64 -> equal
+      [1].At.loc *time.Location: ptr -> equal`
+		tq.Equal(t, expected, tq.Explain(a, b))
+	})
+
+	t.Run("visited", func(t *testing.T) {
+		type node struct {
+			next *node
+		}
+		n1 := &node{}
+		n1.next = n1
+		n2 := &node{}
+		n2.next = n2
+		expected := `(root) *teq_test.node: ptr -> equal
+  (root) teq_test.node: struct -> equal
+    .next *teq_test.node: ptr -> equal
+      .next teq_test.node: already visited, assumed equal -> equal`
+		tq.Equal(t, expected, tq.Explain(n1, n2))
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		tq.Equal(t, "(root): types differ: int vs int64 -> not equal", tq.Explain(1, int64(1)))
+	})
+
+	t.Run("verbose", func(t *testing.T) {
+		tq := teq.New()
+		tq.Verbose = true
+		mt := &mockT{}
+		tq.Equal(mt, 1, 1)
+		tq.Equal(t, []string{"(root) int: int -> equal"}, mt.logs)
+	})
+}
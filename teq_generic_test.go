@@ -0,0 +1,31 @@
+package teq_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/seiyab/teq"
+)
+
+func TestEqualT(t *testing.T) {
+	assert := teq.New()
+
+	mt := &mockT{}
+	assert.Equal(t, true, teq.EqualT(assert, mt, uint8(5), 5))
+	assert.Equal(t, false, teq.EqualT(assert, mt, 1.5, 2))
+	assert.Equal(t, true, teq.NotEqualT(assert, mt, []string{"a"}, nil))
+	assert.Equal(t, false, teq.NotEqualT(assert, mt, "a", "a", "case %d", 1))
+	assert.Equal(t, []string{
+		scalarReport("float64(1.5)", "float64(2)", "+0.5"),
+		"case 1\nreflect.DeepEqual(expected, actual) == true.",
+	}, mt.errors)
+
+	t.Run("interface", func(t *testing.T) {
+		mt := &mockT{}
+		r := strings.NewReader("a")
+		assert.Equal(t, true, teq.EqualT[io.Reader](assert, mt, r, r))
+		assert.Equal(t, false, teq.EqualT[io.Reader](assert, mt, r, nil))
+		assert.Equal(t, 1, len(mt.errors))
+	})
+}
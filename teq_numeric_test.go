@@ -0,0 +1,82 @@
+package teq_test
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/seiyab/teq"
+)
+
+func TestEqual_NumericEquivalence(t *testing.T) {
+	tq := teq.New()
+	tq.NumericEquivalence = true
+
+	tests := []struct {
+		name     string
+		expected any
+		actual   any
+		equal    bool
+	}{
+		{"int and float", 1, 1.0, true},
+		{"int64 and json.Number", int64(42), json.Number("42"), true},
+		{"float and json.Number exponent", 1500.0, json.Number("1.5e3"), true},
+		{"different values", 1, 1.5, false},
+		{"max uint64 and float", uint64(math.MaxUint64), float64(math.MaxUint64), false},
+		{"max int64 and uint64", int64(math.MaxInt64), uint64(math.MaxInt64), true},
+		{"float32 precision", float32(0.1), 0.1, false},
+		{"json.Number decimal", json.Number("0.1"), 0.1, false},
+		{"json.Numbers", json.Number("1.0"), json.Number("1"), true},
+		{"json.Numbers differ", json.Number("1.0"), json.Number("2"), false},
+		{"invalid json.Numbers", json.Number("x"), json.Number("x"), true},
+		{"infinities", float32(math.Inf(1)), math.Inf(1), true},
+		{"NaN", float32(math.NaN()), math.NaN(), false},
+		{"invalid json.Number", json.Number("x"), 0, false},
+		{"hexadecimal json.Number", json.Number("0x10"), 16, false},
+		{"fraction json.Number", json.Number("1/2"), 0.5, false},
+		{"nested", map[string]any{"a": int64(1), "b": []any{2}}, map[string]any{"a": 1.0, "b": []any{json.Number("2")}}, true},
+		{"not numbers", "1", 1, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mt := &mockT{}
+			if got := tq.Equal(mt, test.expected, test.actual); got != test.equal {
+				t.Errorf("expected %t, got %t: %q", test.equal, got, mt.errors)
+			}
+		})
+	}
+
+	t.Run("default", func(t *testing.T) {
+		mt := &mockT{}
+		assert := teq.New()
+		assert.Equal(t, false, teq.New().Equal(mt, 1, 1.0))
+	})
+
+	t.Run("note", func(t *testing.T) {
+		mt := &mockT{}
+		tq.Equal(mt, map[string]any{"a": int64(1), "b": 2}, map[string]any{"a": 1.0, "b": 3})
+		if len(mt.errors) != 1 {
+			t.Fatalf("expected 1 error, got %d", len(mt.errors))
+		}
+		note := `numeric types differ at ["a"]: int64 vs float64, compared by value`
+		if !strings.HasSuffix(mt.errors[0], "\n"+note) {
+			t.Errorf("expected %q to end with %q", mt.errors[0], note)
+		}
+	})
+
+	t.Run("value report", func(t *testing.T) {
+		assert := teq.New()
+		mt := &mockT{}
+		tq.Equal(mt, 1, int64(2))
+		tq.Equal(mt, []any{int64(1)}, []any{json.Number("2")})
+		if len(mt.errors) != 2 {
+			t.Fatalf("expected 2 errors, got %d", len(mt.errors))
+		}
+		assert.Equal(t, "not equal\ndifferences:\n--- expected\n+++ actual\n- int(1)\n+ int64(2)\nnumeric types differ at (root): int vs int64, compared by value", mt.errors[0])
+		note := "numeric types differ at [0]: int64 vs encoding/json.Number, compared by value"
+		if !strings.HasSuffix(mt.errors[1], "\n"+note) {
+			t.Errorf("expected %q to end with %q", mt.errors[1], note)
+		}
+	})
+}
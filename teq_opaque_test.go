@@ -0,0 +1,67 @@
+package teq_test
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/seiyab/teq"
+)
+
+type handle struct {
+	name string
+	ptr  unsafe.Pointer
+	ch   chan int
+	fn   func()
+}
+
+func capture(n int) func() {
+	return func() { _ = n }
+}
+
+func TestEqual_Opaque(t *testing.T) {
+	assert := teq.New()
+	x, y := 1, 1
+	c1, c2 := make(chan int), make(chan int)
+	f := func() {}
+
+	tests := []struct {
+		name   string
+		setup  func(tq *teq.Teq)
+		a, b   handle
+		equal  bool
+		errors []string
+	}{
+		{"unsafe pointer identity", nil, handle{ptr: unsafe.Pointer(&x)}, handle{ptr: unsafe.Pointer(&x)}, true, nil},
+		{"unsafe pointer different", nil, handle{ptr: unsafe.Pointer(&x)}, handle{ptr: unsafe.Pointer(&y)}, false, nil},
+		{"unexported channel identity", nil, handle{ch: c1}, handle{ch: c1}, true, nil},
+		{"unexported channel different", nil, handle{ch: c1}, handle{ch: c2}, false, nil},
+		{"func default", nil, handle{fn: f}, handle{fn: f}, false, nil},
+		{"func default closures", nil, handle{fn: capture(1)}, handle{fn: capture(2)}, false, nil},
+		{"func nil", nil, handle{}, handle{}, true, nil},
+		{"func identity", func(tq *teq.Teq) { tq.Funcs = teq.OpaqueIdentity }, handle{fn: f}, handle{fn: f}, true, nil},
+		// Closures share the code of their function literal.
+		{"func identity closures", func(tq *teq.Teq) { tq.Funcs = teq.OpaqueIdentity }, handle{fn: capture(1)}, handle{fn: capture(2)}, true, nil},
+		{"channel ignored", func(tq *teq.Teq) { tq.Chans = teq.OpaqueIgnore }, handle{ch: c1}, handle{ch: nil}, true, nil},
+		{"unsafe pointer ignored", func(tq *teq.Teq) { tq.UnsafePointers = teq.OpaqueIgnore }, handle{ptr: unsafe.Pointer(&x)}, handle{ptr: unsafe.Pointer(&y)}, true, nil},
+		{
+			"channel fail", func(tq *teq.Teq) { tq.Chans = teq.OpaqueFail },
+			handle{name: "a", ch: c1}, handle{name: "a", ch: c1}, false,
+			[]string{"refused to compare chan int at .ch. set Teq.Chans to OpaqueIgnore or OpaqueIdentity, or register an equal function for the type."},
+		},
+		{"fail allows nil", func(tq *teq.Teq) { tq.Funcs = teq.OpaqueFail }, handle{}, handle{}, true, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tq := teq.New()
+			tq.Reporter = teq.PathReporter{}
+			if test.setup != nil {
+				test.setup(&tq)
+			}
+			mt := &mockT{}
+			assert.Equal(t, test.equal, tq.Equal(mt, test.a, test.b))
+			if test.errors != nil {
+				assert.Equal(t, test.errors, mt.errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package teq_test
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/seiyab/teq"
+)
+
+func TestEventually(t *testing.T) {
+	assert := teq.New()
+
+	t.Run("becomes equal", func(t *testing.T) {
+		var n int32
+		mt := &mockT{}
+		ok := assert.Eventually(mt, 3, func() int { return int(atomic.AddInt32(&n, 1)) }, time.Second, time.Millisecond)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, []string(nil), mt.errors)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		var n int32
+		mt := &mockT{}
+		ok := assert.Eventually(mt, 10, func() int {
+			if atomic.AddInt32(&n, 1) < 3 {
+				return 1
+			}
+			return 2
+		}, 100*time.Millisecond, 5*time.Millisecond)
+		assert.Equal(t, false, ok)
+		if len(mt.errors) != 1 {
+			t.Fatalf("expected 1 error, got %d", len(mt.errors))
+		}
+		msg := mt.errors[0]
+		if !strings.HasPrefix(msg, "not equal after ") || !strings.Contains(msg, "context deadline exceeded\n") {
+			t.Errorf("unexpected head: %q", msg)
+		}
+		if !strings.Contains(msg, "- int(10)\n+ int(2)\ndelta: -8\n") {
+			t.Errorf("expected the last diff, got %q", msg)
+		}
+		if !strings.Contains(msg, "\nhistory:\n#1-#2: int(1)\n#3") || !strings.HasSuffix(msg, ": int(2)") {
+			t.Errorf("expected the history, got %q", msg)
+		}
+	})
+
+	t.Run("context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		mt := &mockT{}
+		ok := assert.EventuallyContext(ctx, mt, 1, func() int { return 2 }, time.Hour)
+		assert.Equal(t, false, ok)
+		if len(mt.errors) != 1 || !strings.HasPrefix(mt.errors[0], "not equal after 1 attempt(s): context canceled\n") {
+			t.Errorf("unexpected errors: %q", mt.errors)
+		}
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		mt := &mockT{}
+		ok := assert.Eventually(mt, 1, func() int { panic("boom") }, time.Second, time.Millisecond)
+		assert.Equal(t, false, ok)
+		if len(mt.errors) != 1 || !strings.HasPrefix(mt.errors[0], "panic in poll func() int: boom\n") ||
+			!strings.Contains(mt.errors[0], "runtime/debug.Stack") {
+			t.Errorf("unexpected errors: %q", mt.errors)
+		}
+	})
+
+	t.Run("invalid function", func(t *testing.T) {
+		mt := &mockT{}
+		ok := assert.Eventually(mt, 1, func(int) int { return 1 }, time.Second, time.Millisecond)
+		assert.Equal(t, false, ok)
+		assert.Equal(t, []string{"f must be a function without arguments returning one value, got func(int) int"}, mt.errors)
+	})
+}
+
+func TestConsistently(t *testing.T) {
+	assert := teq.New()
+
+	t.Run("stays equal", func(t *testing.T) {
+		mt := &mockT{}
+		ok := assert.Consistently(mt, "ready", func() string { return "ready" }, 20*time.Millisecond, time.Millisecond)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, []string(nil), mt.errors)
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		var n int32
+		mt := &mockT{}
+		ok := assert.Consistently(mt, 1, func() int {
+			if atomic.AddInt32(&n, 1) < 3 {
+				return 1
+			}
+			return 2
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, false, ok)
+		expected := `not equal at attempt 3
+not equal
+differences:
+--- expected
++++ actual
+- int(1)
++ int(2)
+delta: +1
+history:
+#1-#2: int(1)
+#3: int(2)`
+		assert.Equal(t, []string{expected}, mt.errors)
+	})
+}
@@ -0,0 +1,101 @@
+package teq_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/seiyab/teq"
+)
+
+func TestTryAdd(t *testing.T) {
+	t.Run("signature", func(t *testing.T) {
+		tq := teq.New()
+		tests := []struct {
+			name   string
+			try    func() error
+			role   string
+			reason string
+		}{
+			{"transform nil", func() error { return tq.TryAddTransform(nil) }, "transform", "transform must be a function"},
+			{"transform non-func", func() error { return tq.TryAddTransform(1) }, "transform", "transform must be a function"},
+			{"transform two args", func() error { return tq.TryAddTransform(func(a, b int) int { return a }) }, "transform", "transform must have only one argument"},
+			{"format without context", func() error { return tq.TryAddFormat(func(a, b int) string { return "" }) }, "format", "format with two arguments must take FormatContext as the first argument"},
+			{"format returns int", func() error { return tq.TryAddFormat(func(a int) int { return a }) }, "format", "format must return string"},
+			{"equal different types", func() error { return tq.TryAddEqual(func(a int, b string) bool { return true }) }, "equal", "equal must have two arguments with the same type"},
+			{"equal returns int", func() error { return tq.TryAddEqual(func(a, b int) int { return 0 }) }, "equal", "equal must return bool"},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				err := test.try()
+				var se *teq.SignatureError
+				if !errors.As(err, &se) {
+					t.Fatalf("expected *teq.SignatureError, got %v", err)
+				}
+				if se.Role != test.role || se.Reason != test.reason {
+					t.Errorf("expected role %q and reason %q, got %q and %q", test.role, test.reason, se.Role, se.Reason)
+				}
+			})
+		}
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		tq := teq.New()
+		if err := tq.TryAddTransform(utc); err != nil {
+			t.Fatal(err)
+		}
+		err := tq.TryAddEqual(func(a, b time.Time) bool { return a.Equal(b) })
+		var ce *teq.ConflictError
+		if !errors.As(err, &ce) {
+			t.Fatalf("expected *teq.ConflictError, got %v", err)
+		}
+		expected := "equal for time.Time conflicts with transform registered for the same type"
+		if ce.Error() != expected {
+			t.Errorf("expected %q, got %q", expected, ce.Error())
+		}
+		if err := tq.TryAddTransform(utc); err != nil {
+			t.Errorf("re-registering transform must be allowed, got %v", err)
+		}
+		err = tq.TryAddTransform(func(t time.Time) time.Time { return t.Local() })
+		if !errors.As(err, &ce) || ce.Registered != "transform" {
+			t.Errorf("expected *teq.ConflictError with another transform, got %v", err)
+		}
+	})
+
+	t.Run("format conflict", func(t *testing.T) {
+		tq := teq.New()
+		format := func(d time.Duration) string { return d.String() }
+		if err := tq.TryAddFormat(format); err != nil {
+			t.Fatal(err)
+		}
+		if err := tq.TryAddFormat(format); err != nil {
+			t.Errorf("re-registering format must be allowed, got %v", err)
+		}
+		err := tq.TryAddFormat(func(d time.Duration) string { return "" })
+		expected := "format for time.Duration conflicts with format registered for the same type"
+		if err == nil || err.Error() != expected {
+			t.Errorf("expected %q, got %v", expected, err)
+		}
+	})
+
+	t.Run("Add doesn't check conflicts", func(t *testing.T) {
+		tq := teq.New()
+		tq.AddTransform(utc)
+		tq.AddEqual(func(a, b time.Time) bool { return true })
+		mt := &mockT{}
+		if !tq.Equal(mt, time.Unix(0, 0), time.Unix(1, 0)) {
+			t.Error("equal must take precedence over transform")
+		}
+	})
+
+	t.Run("Add panics", func(t *testing.T) {
+		defer func() {
+			r := recover()
+			if _, ok := r.(*teq.SignatureError); !ok {
+				t.Errorf("expected panic with *teq.SignatureError, got %v", r)
+			}
+		}()
+		tq := teq.New()
+		tq.AddFormat(func(a int) {})
+	})
+}
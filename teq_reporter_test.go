@@ -0,0 +1,139 @@
+package teq_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seiyab/teq"
+)
+
+type dashboardReporter struct{}
+
+func (dashboardReporter) Report(r teq.Report) string {
+	var paths []string
+	for _, d := range r.Differences() {
+		paths = append(paths, d.Path)
+	}
+	return "dashboard: " + strings.Join(paths, ",")
+}
+
+func TestReporter(t *testing.T) {
+	type item struct {
+		Name string
+		At   time.Time
+		Tags map[string]int
+	}
+	d := time.Date(2000, 2, 1, 12, 30, 0, 0, time.UTC)
+	a := []item{{"a", d, map[string]int{"x": 1}}, {"b", d, nil}}
+	b := []item{{"a", d.Add(time.Hour), map[string]int{"y": 1}}, {"c", d, nil}}
+
+	newTeq := func(r teq.Reporter) teq.Teq {
+		tq := teq.New()
+		tq.AddFormat(func(d time.Time) string {
+			return d.Format(time.RFC3339)
+		})
+		tq.Reporter = r
+		return tq
+	}
+
+	t.Run("path", func(t *testing.T) {
+		mt := &mockT{}
+		newTeq(teq.PathReporter{}).Equal(mt, a, b)
+		expected := `not equal: 4 difference(s)
+[0].At: expected time.Time("2000-02-01T12:30:00Z"), actual time.Time("2000-02-01T13:30:00Z")
+[0].Tags["x"]: expected 1, actual <absent>
+[0].Tags["y"]: expected <absent>, actual 1
+[1].Name: expected "b", actual "c"`
+		assert := teq.New()
+		assert.Equal(t, []string{expected}, mt.errors)
+	})
+
+	t.Run("NaN keys", func(t *testing.T) {
+		mt := &mockT{}
+		newTeq(teq.PathReporter{}).Equal(mt, []any{map[float64]int{math.NaN(): 1}}, []any{"x"})
+		expected := "not equal: 1 difference(s)\n[0]: expected map[float64]int{NaN: 1}, actual \"x\""
+		assert := teq.New()
+		assert.Equal(t, []string{expected}, mt.errors)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		mt := &mockT{}
+		newTeq(teq.JSONReporter{}).Equal(mt, map[string]int{"a": 1}, map[string]int{"a": 2})
+		expected := `{"differences":[{"path":"[\"a\"]","expected":"1","actual":"2"}]}`
+		assert := teq.New()
+		assert.Equal(t, []string{expected}, mt.errors)
+	})
+
+	t.Run("side by side", func(t *testing.T) {
+		type s struct {
+			i int
+		}
+		mt := &mockT{}
+		newTeq(teq.SideBySideReporter{}).Equal(mt, s{1}, s{2})
+		expected := `not equal
+differences:
+expected      | actual
+  teq_test.s{ |   teq_test.s{
+-   i: 1,     | +   i: 2,
+  }           |   }`
+		assert := teq.New()
+		assert.Equal(t, []string{expected}, mt.errors)
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		mt := &mockT{}
+		newTeq(dashboardReporter{}).Equal(mt, a, b)
+		assert := teq.New()
+		assert.Equal(t, []string{`dashboard: [0].At,[0].Tags["x"],[0].Tags["y"],[1].Name`}, mt.errors)
+	})
+}
+
+func TestEqual_MessageAndLabels(t *testing.T) {
+	assert := teq.New()
+
+	t.Run("message", func(t *testing.T) {
+		mt := &mockT{}
+		tq := teq.New()
+		tq.Equal(mt, 1, 2, "case %s", "one")
+		tq.Equal(mt, "a", "b", "no format args")
+		tq.NotEqual(mt, 1, 1, 42)
+		tq.Equal(mt, 1, 1, "never shown")
+		assert.Equal(t, []string{
+			"case one\n" + scalarReport("int(1)", "int(2)", "+1"),
+			"no format args\nexpected a, got b",
+			"42\nreflect.DeepEqual(expected, actual) == true.",
+		}, mt.errors)
+	})
+
+	t.Run("labels", func(t *testing.T) {
+		type s struct {
+			i int
+		}
+		tq := teq.New()
+		tq.ExpectedLabel = "want"
+		tq.ActualLabel = "got"
+
+		mt := &mockT{}
+		tq.Equal(mt, 1, 2)
+		tq.Equal(mt, "a", "b")
+		tq.Equal(mt, 1, "1")
+		assert.Equal(t, []string{
+			"not equal\ndifferences:\n--- want\n+++ got\n- int(1)\n+ int(2)\ndelta: +1",
+			"want a, got b",
+			"type mismatch\n--- want: int\n+++ got: string\n- int(1)\n+ string(\"1\")",
+		}, mt.errors)
+
+		mt = &mockT{}
+		tq.Reporter = teq.PathReporter{}
+		tq.Equal(mt, s{1}, s{2})
+		assert.Equal(t, []string{"not equal: 1 difference(s)\n.i: want 1, got 2"}, mt.errors)
+
+		type parity int
+		tq.AddTransform(func(p parity) int { return int(p) % 2 })
+		mt = &mockT{}
+		tq.NotEqual(mt, parity(1), parity(3))
+		assert.Equal(t, []string{"want teq_test.parity(1) == got teq_test.parity(3), expected them to differ"}, mt.errors)
+	})
+}
@@ -0,0 +1,131 @@
+package teq_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/seiyab/teq"
+)
+
+type userDTO struct {
+	ID      int
+	Name    string
+	Tags    []tagDTO
+	Manager *userDTO
+	Extra   string
+}
+
+type tagDTO struct {
+	Label string
+}
+
+type user struct {
+	ID      int
+	Name    string
+	Tags    []tag
+	Manager *user
+	Created time.Time
+}
+
+type tag struct {
+	Label string
+}
+
+func TestEqual_Structural(t *testing.T) {
+	assert := teq.New()
+	newTeq := func(policy teq.OneSidedPolicy) teq.Teq {
+		tq := teq.New()
+		tq.Structural = true
+		tq.OneSidedFields = policy
+		tq.Reporter = teq.PathReporter{}
+		return tq
+	}
+	dto := userDTO{ID: 1, Name: "a", Tags: []tagDTO{{"x"}}, Manager: &userDTO{ID: 2}}
+	model := user{ID: 1, Name: "a", Tags: []tag{{"x"}}, Manager: &user{ID: 2}}
+
+	t.Run("one-sided fields", func(t *testing.T) {
+		tests := []struct {
+			policy teq.OneSidedPolicy
+			dto    userDTO
+			equal  bool
+		}{
+			{teq.OneSidedFail, dto, false},
+			{teq.OneSidedIgnore, dto, true},
+			{teq.OneSidedZero, dto, true},
+			{teq.OneSidedZero, userDTO{ID: 1, Name: "a", Tags: []tagDTO{{"x"}}, Manager: &userDTO{ID: 2}, Extra: "e"}, false},
+		}
+		for _, test := range tests {
+			assert.Equal(t, test.equal, newTeq(test.policy).Equal(&mockT{}, test.dto, model), "policy %d", test.policy)
+		}
+	})
+
+	t.Run("differences", func(t *testing.T) {
+		mt := &mockT{}
+		other := user{ID: 1, Name: "b", Tags: []tag{{"y"}}, Manager: &user{ID: 3}}
+		newTeq(teq.OneSidedIgnore).Equal(mt, dto, other)
+		expected := `not equal: 3 difference(s)
+.Name: expected "a", actual "b"
+.Tags[0].Label: expected "x", actual "y"
+.Manager.ID: expected 2, actual 3`
+		assert.Equal(t, []string{expected}, mt.errors)
+	})
+
+	t.Run("map", func(t *testing.T) {
+		tq := newTeq(teq.OneSidedFail)
+		type point struct {
+			X, Y int
+			Tag  *string
+		}
+		assert.Equal(t, true, tq.Equal(&mockT{}, point{1, 2, nil}, map[string]any{"X": 1, "Y": 2, "Tag": nil}))
+
+		mt := &mockT{}
+		tq.Equal(mt, point{1, 2, nil}, map[string]any{"X": 1, "Y": "2", "Z": 3})
+		expected := `not equal: 3 difference(s)
+.Y: expected 2, actual "2"
+.Tag: expected *string(nil), actual <absent>
+.Z: expected <absent>, actual 3`
+		assert.Equal(t, []string{expected}, mt.errors)
+	})
+
+	t.Run("unified reporter", func(t *testing.T) {
+		tq := teq.New()
+		tq.Structural = true
+		mt := &mockT{}
+		tq.Equal(mt, tagDTO{"x"}, tag{"y"})
+		assert.Equal(t, []string{"not equal: 1 difference(s)\n.Label: expected \"x\", actual \"y\""}, mt.errors)
+	})
+
+	t.Run("embedded", func(t *testing.T) {
+		type base struct {
+			ID int
+		}
+		type outer struct {
+			base
+			Name string
+		}
+		type outerPtr struct {
+			*base
+			Name string
+		}
+		type flat struct {
+			ID   int
+			Name string
+		}
+		tq := newTeq(teq.OneSidedFail)
+		assert.Equal(t, true, tq.Equal(&mockT{}, outer{base{1}, "a"}, flat{1, "a"}))
+		assert.Equal(t, true, tq.Equal(&mockT{}, outerPtr{&base{1}, "a"}, outer{base{1}, "a"}))
+		assert.Equal(t, true, tq.Equal(&mockT{}, outer{base{1}, "a"}, map[string]any{"ID": 1, "Name": "a"}))
+
+		mt := &mockT{}
+		tq.Equal(mt, outer{base{1}, "a"}, flat{2, "a"})
+		tq.Equal(mt, outerPtr{nil, "a"}, flat{0, "a"})
+		assert.Equal(t, []string{
+			"not equal: 1 difference(s)\n.ID: expected 1, actual 2",
+			"not equal: 1 difference(s)\n.ID: expected <absent>, actual 0",
+		}, mt.errors)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		assert.Equal(t, false, teq.New().Equal(&mockT{}, tagDTO{"x"}, tag{"x"}))
+	})
+}
@@ -2,12 +2,21 @@ package teq_test
 
 import (
 	"fmt"
+	"os"
+	"testing"
 
 	"github.com/seiyab/teq"
 )
 
+func TestMain(m *testing.M) {
+	// Messages are compared without colors even if tests run in a terminal.
+	os.Setenv("NO_COLOR", "1")
+	os.Exit(m.Run())
+}
+
 type mockT struct {
 	errors []string
+	logs   []string
 }
 
 var _ teq.TestingT = &mockT{}
@@ -22,4 +31,6 @@ func (t *mockT) Errorf(format string, args ...interface{}) {
 	t.errors = append(t.errors, fmt.Sprintf(format, args...))
 }
 
-func (t *mockT) Log(args ...interface{}) {}
+func (t *mockT) Log(args ...interface{}) {
+	t.logs = append(t.logs, fmt.Sprint(args...))
+}
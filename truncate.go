@@ -0,0 +1,58 @@
+package teq
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// limitDiffLines cuts diff lines after MaxDifferences runs of changed lines.
+func (teq Teq) limitDiffLines(lines []string) []string {
+	if teq.MaxDifferences <= 0 {
+		return lines
+	}
+	groups := 0
+	cut := -1
+	changed := false
+	for i, line := range lines {
+		c := strings.HasPrefix(line, "-") || strings.HasPrefix(line, "+")
+		if c && !changed {
+			groups++
+			if groups == teq.MaxDifferences+1 {
+				cut = i
+			}
+		}
+		changed = c
+	}
+	if cut < 0 {
+		return lines
+	}
+	return append(lines[:cut:cut], omittedDifferences(groups-teq.MaxDifferences))
+}
+
+// limitDifferences cuts differences after MaxDifferences. It returns the number of omitted differences.
+func (teq Teq) limitDifferences(ds []Difference) ([]Difference, int) {
+	if teq.MaxDifferences <= 0 || len(ds) <= teq.MaxDifferences {
+		return ds, 0
+	}
+	return ds[:teq.MaxDifferences], len(ds) - teq.MaxDifferences
+}
+
+func omittedDifferences(n int) string {
+	return fmt.Sprintf("… %d more differences omitted", n)
+}
+
+// limitSize cuts the report at a line boundary so that it doesn't exceed MaxReportSize.
+func (teq Teq) limitSize(msg string) string {
+	if teq.MaxReportSize <= 0 || len(msg) <= teq.MaxReportSize {
+		return msg
+	}
+	cut := strings.LastIndex(msg[:teq.MaxReportSize], "\n")
+	if cut < 0 {
+		cut = teq.MaxReportSize
+		for cut > 0 && !utf8.RuneStart(msg[cut]) {
+			cut--
+		}
+	}
+	return fmt.Sprintf("%s\n… report truncated, %d more bytes omitted", msg[:cut], len(msg)-cut)
+}